@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/database"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var (
+	flagParallel = false
+)
+
+var allCmd = &cobra.Command{
+	Use:   "all [PVR|GROUP]...",
+	Short: "Search for missing and cutoff unmet media files across pvrs",
+	Long: `This command can be used to search for missing and cutoff unmet media files from every configured pvr.
+
+Pvr names or groups from the configuration file can be provided to only search those pvrs.`,
+
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// validate inputs
+		pvrNames, err := resolvePvrNames(args)
+		if err != nil {
+			log.WithError(err).Fatal("Failed validating inputs")
+		}
+
+		// load database
+		if err := database.Init(flagDatabaseFile); err != nil {
+			log.WithError(err).Fatal("Failed opening database file")
+		}
+		defer database.Close()
+
+		runs := make([]*pvrRun, 0, len(pvrNames))
+		for _, name := range pvrNames {
+			run, err := loadPvrRun(name)
+			if err != nil {
+				log.WithError(err).Fatalf("Failed loading pvr: %s", name)
+			}
+
+			runs = append(runs, run)
+		}
+
+		// search pvrs
+		failed := make([]bool, len(runs))
+
+		if flagParallel {
+			initPvrSlots()
+
+			var wg sync.WaitGroup
+			for pos, run := range runs {
+				wg.Add(1)
+				go func(pos int, run *pvrRun) {
+					defer wg.Done()
+
+					acquirePvrSlot()
+					defer releasePvrSlot()
+
+					failed[pos] = !run.processAllWanted()
+				}(pos, run)
+			}
+			wg.Wait()
+		} else {
+			for pos, run := range runs {
+				failed[pos] = !run.processAllWanted()
+			}
+		}
+
+		// show totals
+		for pos, run := range runs {
+			fields := logrus.Fields{
+				"pvr":     run.name,
+				"missing": run.searchedItems["missing"],
+				"cutoff":  run.searchedItems["cutoff"],
+				"failed":  failed[pos],
+			}
+			if !flagDryRun {
+				outcomes := run.searchOutcomeFields("")
+				fields["grabbed"] = outcomes["grabbed"]
+				fields["hit_rate"] = outcomes["hit_rate"]
+			}
+
+			log.WithFields(fields).Info("Searched items")
+		}
+
+		// export dry run batches
+		if err := exportDryRunBatches(runs...); err != nil {
+			log.WithError(err).Fatal("Failed exporting dry run batches")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(allCmd)
+
+	allCmd.Flags().BoolVarP(&flagParallel, "parallel", "p", false, "Search pvrs at the same time.")
+	allCmd.Flags().IntVar(&flagMaxParallel, "max-parallel", 0, "How many pvrs to search at the same time (default core.max_parallel_pvrs, 0 is unlimited).")
+	allCmd.Flags().IntVarP(&maxQueueSize, "queue-size", "q", 0, "Stop searching a pvr when queue size reached.")
+	allCmd.Flags().BoolVar(&flagQueueWait, "queue-wait", false, "Pause instead of stopping when queue size reached.")
+	allCmd.Flags().IntVar(&flagQueueLow, "queue-low", 0, "Resume a paused search when queue size drops to this (default half of queue-size).")
+	allCmd.Flags().DurationVar(&flagQueueDeadline, "queue-deadline", 0, "Stop waiting for the queue once searching has run this long.")
+	allCmd.Flags().IntVarP(&maxSearchItems, "max-search", "m", 0, "Stop searching a wanted list when this many items have been searched.")
+	allCmd.Flags().IntVarP(&searchBatchSize, "search-size", "s", 10, "How many items to search at once.")
+	allCmd.Flags().StringVarP(&flagSearchOrder, "order", "o", "", "Order to search items in (newest, oldest, least_recent, random, round_robin).")
+	allCmd.Flags().BoolVarP(&flagRefreshCache, "refresh-cache", "r", false, "Refresh the locally stored cache.")
+	allCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "Show the items that would be searched without searching them.")
+	allCmd.Flags().StringVar(&flagDryRunExport, "dry-run-export", "", "Export the batches a dry run would search to this JSON file.")
+}
+
+/* Private Helpers */
+
+func resolvePvrNames(args []string) ([]string, error) {
+	// default to every configured pvr
+	if len(args) == 0 {
+		pvrNames := make([]string, 0, len(config.Config.Pvr))
+		for name := range config.Config.Pvr {
+			pvrNames = append(pvrNames, name)
+		}
+		sort.Strings(pvrNames)
+
+		return pvrNames, nil
+	}
+
+	// expand groups
+	var pvrNames []string
+	seen := make(map[string]bool)
+
+	for _, arg := range args {
+		names := []string{arg}
+		if _, ok := config.Config.Pvr[arg]; !ok {
+			group, ok := config.Config.Groups[arg]
+			if !ok {
+				return nil, fmt.Errorf("no pvr or group configuration found for: %q", arg)
+			}
+
+			names = group
+		}
+
+		for _, name := range names {
+			if seen[name] {
+				continue
+			}
+
+			seen[name] = true
+			pvrNames = append(pvrNames, name)
+		}
+	}
+
+	return pvrNames, nil
+}
+
+func (r *pvrRun) processAllWanted() bool {
+	// init pvr object
+	if err := r.pvr.Init(); err != nil {
+		r.log.WithError(err).Errorf("Failed initializing pvr object for: %s", r.name)
+		return false
+	}
+
+	// search for wanted media
+	ok := true
+	for _, wantedType := range []string{"missing", "cutoff"} {
+		if !continueRunning.Load() {
+			break
+		}
+
+		if _, err := r.processWanted(wantedType); err != nil {
+			r.log.WithError(err).Errorf("Failed searching for %s media...", wantedDescription(wantedType))
+			ok = false
+		}
+	}
+
+	return ok
+}
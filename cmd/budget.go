@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/* Private Helpers */
+
+// periodBudgets returns how many more items can be searched within each budget period that has a budget set
+func (r *pvrRun) periodBudgets() (map[string]int, error) {
+	now := time.Now().UTC()
+	remaining := make(map[string]int)
+
+	budgets := []struct {
+		name   string
+		limit  int
+		period time.Duration
+	}{
+		{"daily", r.cfg.Budget.Daily, 24 * time.Hour},
+		{"weekly", r.cfg.Budget.Weekly, 7 * 24 * time.Hour},
+	}
+
+	for _, budget := range budgets {
+		if budget.limit <= 0 {
+			continue
+		}
+
+		searched, err := database.GetSearchedItemsCount(r.lowerName, now.Add(-budget.period))
+		if err != nil {
+			return nil, errors.WithMessagef(err, "failed retrieving %s searched items count", budget.name)
+		}
+
+		// dry runs are not logged, so count the items they would have searched
+		searched += r.dryRunSearchedItems()
+
+		left := budget.limit - searched
+		if left < 0 {
+			left = 0
+		}
+		remaining[budget.name] = left
+	}
+
+	return remaining, nil
+}
+
+// remainingBudget returns how many more items can be searched, or -1 when no budget is set
+func (r *pvrRun) remainingBudget() (int, error) {
+	budgets, err := r.periodBudgets()
+	if err != nil {
+		return 0, err
+	}
+
+	remaining := -1
+	for _, left := range budgets {
+		if remaining < 0 || left < remaining {
+			remaining = left
+		}
+	}
+
+	return remaining, nil
+}
+
+func (r *pvrRun) logRemainingBudget() {
+	budgets, err := r.periodBudgets()
+	if err != nil {
+		r.log.WithError(err).Error("Failed retrieving searched items count...")
+		return
+	} else if len(budgets) == 0 {
+		return
+	}
+
+	fields := logrus.Fields{}
+	for name, left := range budgets {
+		fields[name+"_remaining"] = left
+	}
+
+	r.log.WithFields(fields).Info("Search budget")
+}
+
+// limitToBudget trims the batch to the remaining budget, returning false when the budget is exhausted
+func (r *pvrRun) limitToBudget(searchItems []pvrObj.MediaItem) ([]pvrObj.MediaItem, bool) {
+	remaining, err := r.remainingBudget()
+	if err != nil {
+		r.log.WithError(err).Error("Failed checking search budget, aborting...")
+		r.continueRunning.Store(false)
+		return nil, false
+	}
+
+	if remaining < 0 || remaining >= len(searchItems) {
+		return searchItems, true
+	}
+
+	if remaining == 0 {
+		r.log.Warn("Search budget has been reached, aborting...")
+		r.continueRunning.Store(false)
+		return nil, false
+	}
+
+	r.log.WithField("remaining", remaining).Warn("Search budget nearly reached, trimming batch...")
+	return searchItems[:remaining], true
+}
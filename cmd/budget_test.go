@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/database"
+	pvrObj "github.com/migz93/wantarr/pvr"
+)
+
+/* Test Budget Limit */
+
+func TestLimitToBudget(t *testing.T) {
+	defer openTestDatabase(t)()
+
+	now := time.Now().UTC()
+	batch := make([]pvrObj.MediaItem, 10)
+
+	tests := []struct {
+		name         string
+		budget       config.Budget
+		searched     []time.Duration // age of each logged search of 5 items
+		dryRunItems  int
+		items        int
+		withinBudget bool
+	}{
+		{"no-budget", config.Budget{}, []time.Duration{0, 0}, 0, 10, true},
+		{"within-daily", config.Budget{Daily: 20}, []time.Duration{0}, 0, 10, true},
+		{"trim-daily", config.Budget{Daily: 18}, []time.Duration{0, time.Hour}, 0, 8, true},
+		{"exhausted-daily", config.Budget{Daily: 10}, []time.Duration{0, 0}, 0, 0, false},
+		{"expired-daily", config.Budget{Daily: 10}, []time.Duration{25 * time.Hour, 30 * time.Hour}, 0, 10, true},
+		{"trim-weekly", config.Budget{Daily: 20, Weekly: 12}, []time.Duration{48 * time.Hour}, 0, 7, true},
+		{"exhausted-weekly", config.Budget{Daily: 20, Weekly: 10}, []time.Duration{48 * time.Hour, 72 * time.Hour},
+			0, 0, false},
+		{"dry-run", config.Budget{Daily: 20}, nil, 14, 6, true},
+		{"dry-run-exhausted", config.Budget{Daily: 20}, []time.Duration{0}, 15, 0, false},
+	}
+
+	for _, tc := range tests {
+		r := newPvrRun(tc.name, &config.Pvr{Budget: tc.budget}, nil)
+
+		for _, age := range tc.searched {
+			if err := database.AddSearchLog(r.lowerName, "missing", 5, now.Add(-age)); err != nil {
+				t.Fatalf("Failed adding search log: %v", err)
+			}
+		}
+		if tc.dryRunItems > 0 {
+			r.recordDryRunBatch(make([]pvrObj.MediaItem, tc.dryRunItems), "missing", "")
+		}
+
+		items, withinBudget := r.limitToBudget(batch)
+		if len(items) != tc.items || withinBudget != tc.withinBudget {
+			t.Errorf("Expected %d items within budget %t for %s but got %d items within budget %t", tc.items,
+				tc.withinBudget, tc.name, len(items), withinBudget)
+		}
+
+		// an exhausted budget stops the run
+		if r.continueRunning.Load() != tc.withinBudget {
+			t.Errorf("Expected running %t for %s after checking the budget", tc.withinBudget, tc.name)
+		}
+	}
+}
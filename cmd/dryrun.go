@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	flagDryRun       = false
+	flagDryRunExport = ""
+)
+
+/* Structs */
+
+type dryRunBatch struct {
+	Pvr        string       `json:"pvr"`
+	WantedType string       `json:"wanted_type"`
+	Group      string       `json:"group,omitempty"`
+	Items      []dryRunItem `json:"items"`
+}
+
+type dryRunItem struct {
+	Id           int       `json:"id"`
+	Title        string    `json:"title,omitempty"`
+	ParentId     int       `json:"parent_id,omitempty"`
+	ParentTitle  string    `json:"parent_title,omitempty"`
+	SeasonNumber int       `json:"season_number,omitempty"`
+	AirDateUtc   time.Time `json:"air_date_utc"`
+}
+
+/* Private Helpers */
+
+func (r *pvrRun) recordDryRunBatch(searchItems []pvrObj.MediaItem, wantedType string, searchGroup string) {
+	batch := dryRunBatch{
+		Pvr:        r.name,
+		WantedType: wantedType,
+		Group:      searchGroup,
+		Items:      make([]dryRunItem, 0, len(searchItems)),
+	}
+
+	for _, item := range searchItems {
+		batch.Items = append(batch.Items, dryRunItem{
+			Id:           item.ItemId,
+			Title:        item.Title,
+			ParentId:     item.ParentId,
+			ParentTitle:  item.ParentTitle,
+			SeasonNumber: item.SeasonNumber,
+			AirDateUtc:   item.AirDateUtc,
+		})
+	}
+
+	r.dryRunBatches = append(r.dryRunBatches, batch)
+
+	r.log.WithFields(logrus.Fields{
+		"batch":       len(r.dryRunBatches),
+		"wanted_type": wantedType,
+		"group":       searchGroup,
+		"media_items": pluckMediaItemIds(searchItems),
+	}).Info("Dry run, would search")
+}
+
+func exportDryRunBatches(runs ...*pvrRun) error {
+	if flagDryRunExport == "" {
+		return nil
+	}
+
+	batches := make([]dryRunBatch, 0)
+	for _, run := range runs {
+		batches = append(batches, run.dryRunBatches...)
+	}
+
+	bs, err := json.MarshalIndent(batches, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed encoding dry run batches")
+	}
+
+	if err := ioutil.WriteFile(flagDryRunExport, bs, 0644); err != nil {
+		return errors.Wrapf(err, "failed writing dry run batches to: %q", flagDryRunExport)
+	}
+
+	log.WithField("batches", len(batches)).Infof("Exported dry run batches to %q", flagDryRunExport)
+	return nil
+}
+
+// dryRunSearchedItems returns how many items this dry run would have searched, counted against the budget
+func (r *pvrRun) dryRunSearchedItems() int {
+	searched := 0
+	for _, batch := range r.dryRunBatches {
+		searched += len(batch.Items)
+	}
+
+	return searched
+}
@@ -0,0 +1,106 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	"github.com/spf13/cobra"
+)
+
+var explainCmd = &cobra.Command{
+	Use:   "explain [PVR] [missing|cutoff]",
+	Short: "Explain which cached media files would be searched",
+	Long: `This command can be used to show why each cached media file would be kept or dropped before searching.
+
+Media files are checked against the retry days age / backoff and the select rules set for the pvr.`,
+
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		// validate inputs
+		wantedType := args[1]
+		if wantedType != "missing" && wantedType != "cutoff" {
+			log.Fatalf("Unsupported wanted type: %q", wantedType)
+		}
+
+		// load database
+		if err := database.Init(flagDatabaseFile); err != nil {
+			log.WithError(err).Fatal("Failed opening database file")
+		}
+		defer database.Close()
+
+		run, err := parseValidateInputs(args)
+		if err != nil {
+			log.WithError(err).Fatal("Failed validating inputs")
+		}
+
+		if err := run.loadSelectRules(); err != nil {
+			log.WithError(err).Fatal("Failed loading select rules")
+		}
+
+		// refresh cached media items
+		if flagRefreshCache {
+			if err := run.pvr.Init(); err != nil {
+				log.WithError(err).Fatalf("Failed initializing pvr object for: %s", run.name)
+			}
+
+			if err := run.refreshWantedItems(wantedType); err != nil {
+				log.WithError(err).Fatal("Failed refreshing cached media items")
+			}
+		}
+
+		// explain media items
+		if err := run.explainItems(wantedType); err != nil {
+			log.WithError(err).Fatal("Failed explaining media items")
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(explainCmd)
+
+	explainCmd.Flags().StringVarP(&flagSearchOrder, "order", "o", "", "Order to search items in (newest, oldest, least_recent, random, round_robin).")
+	explainCmd.Flags().BoolVarP(&flagRefreshCache, "refresh-cache", "r", false, "Refresh the locally stored cache.")
+}
+
+/* Private Helpers */
+
+func (r *pvrRun) explainItems(wantedType string) error {
+	mediaItems, err := database.GetMediaItems(r.lowerName, wantedType, false, r.searchOrder())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	keptItems := 0
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "ID\tTITLE\tAIR DATE\tATTEMPTS\tRESULT\tREASON")
+
+	for _, item := range mediaItems {
+		selected, reason := r.selectItem(wantedType, item, now)
+
+		// future media is never searched for missing
+		if wantedType == "missing" && item.AirDateUtc.After(now) {
+			selected, reason = false, "not yet aired"
+		}
+
+		result := "dropped"
+		if selected {
+			result = "kept"
+			keptItems++
+		}
+
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%s\n", item.Id, mediaItemTitle(item),
+			item.AirDateUtc.Format("2006-01-02"), item.SearchAttempts, result, reason)
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d of %d media items would be searched\n", keptItems, len(mediaItems))
+	return nil
+}
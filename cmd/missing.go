@@ -1,11 +1,7 @@
 package cmd
 
 import (
-	"time"
-
 	"github.com/migz93/wantarr/database"
-	pvrObj "github.com/migz93/wantarr/pvr"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 )
 
@@ -16,166 +12,31 @@ var missingCmd = &cobra.Command{
 
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		// validate inputs
-		if err := parseValidateInputs(args); err != nil {
-			log.WithError(err).Fatal("Failed validating inputs")
-		}
-
-		// init pvr object
-		if err := pvr.Init(); err != nil {
-			log.WithError(err).Fatalf("Failed initializing pvr object for: %s", pvrName)
-		}
-
 		// load database
 		if err := database.Init(flagDatabaseFile); err != nil {
 			log.WithError(err).Fatal("Failed opening database file")
 		}
 		defer database.Close()
 
-		// retrieve missing records from pvr and stash in database
-		existingItemsCount := database.GetItemsCount(lowerPvrName, "missing")
-		if flagRefreshCache || existingItemsCount < 1 {
-			log.Infof("Retrieving missing media from %s: %q", (pvrConfig.Type), pvrName)
-
-			missingRecords, err := pvr.GetWantedMissing()
-			if err != nil {
-				log.WithError(err).Fatal("Failed retrieving wanted missing pvr items...")
-			}
-
-			// stash missing media in database
-			log.Debug("Stashing media items in database...")
-
-			if err := database.SetMediaItems(lowerPvrName, "missing", missingRecords); err != nil {
-				log.WithError(err).Fatal("Failed stashing media items in database")
-			}
-
-			log.Info("Stashed media items")
-
-			// remove media no longer missing
-			if existingItemsCount >= 1 {
-				log.Debug("Removing media items from database that are no longer missing...")
-
-				removedItems, err := database.DeleteMissingItems(lowerPvrName, "missing", missingRecords)
-				if err != nil {
-					log.WithError(err).Fatal("Failed removing media items from database that are no longer missing...")
-				}
-
-				log.WithField("removed_items", removedItems).
-					Info("Removed media items from database that are no longer missing")
-			}
-		}
-
-		// start queue monitor
-		if maxQueueSize > 0 {
-			go func() {
-				log.Info("Started queue monitor")
-				for {
-					// retrieve queue size
-					qs, err := pvr.GetQueueSize()
-					if err != nil {
-						log.WithError(err).Error("Failed retrieving queue size, aborting...")
-						continueRunning.Store(false)
-						break
-					}
-
-					// check queue size
-					if qs >= maxQueueSize {
-						log.Warnf("Queue size has been reached, aborting....")
-						continueRunning.Store(false)
-						break
-					}
-
-					// sleep before check
-					time.Sleep(10 * time.Second)
-				}
-				log.Info("Finished queue monitor")
-			}()
-		}
-
-		// get media items from database
-		mediaItems, err := database.GetMediaItems(lowerPvrName, "missing", true)
+		// validate inputs
+		run, err := parseValidateInputs(args)
 		if err != nil {
-			log.WithError(err).Fatal("Failed retrieving media items from database...")
+			log.WithError(err).Fatal("Failed validating inputs")
 		}
-		log.WithField("media_items", len(mediaItems)).Debug("Retrieved media items from database")
-
-		// start searching
-		var searchItems []pvrObj.MediaItem
-		searchedItemsCount := 0
-
-		for _, item := range mediaItems {
-			// abort if required (queue monitor will set this)
-			if !continueRunning.Load() {
-				break
-			}
-
-			// dont search this item if we already searched it within N days
-			if item.LastSearchDateUtc != nil && !item.LastSearchDateUtc.IsZero() {
-				retryAfterDate := item.LastSearchDateUtc.Add((24 * time.Hour) * pvrConfig.RetryDaysAge.Missing)
-				if time.Now().UTC().Before(retryAfterDate) {
-					log.WithField("retry_min_date", retryAfterDate).
-						Tracef("Skipping media item %v until allowed retry date", item.Id)
-					continue
-				}
-			}
-
-			// add item to batch
-			searchItems = append(searchItems, pvrObj.MediaItem{
-				ItemId:     item.Id,
-				AirDateUtc: item.AirDateUtc,
-			})
 
-			// not enough items batched yet
-			batchedItemsCount := len(searchItems)
-			if batchedItemsCount < searchBatchSize {
-				continue
-			}
-
-			// do search
-			log.WithFields(logrus.Fields{
-				"search_items": batchedItemsCount,
-			}).Info("Searching...")
-
-			searchedItemsCount += batchedItemsCount
-
-			if _, err := searchForItems(searchItems, "missing"); err != nil {
-				log.WithError(err).Error("Failed searching for items...")
-			} else {
-				log.WithFields(logrus.Fields{
-					"searched_items": searchedItemsCount,
-				}).Info("Search complete")
-			}
-
-			// reset batch
-			searchItems = []pvrObj.MediaItem{}
-
-			// max search items reached?
-			if maxSearchItems > 0 && searchedItemsCount >= maxSearchItems {
-				log.WithField("searched_items", searchedItemsCount).
-					Info("Max search items reached, aborting...")
-				break
-			}
-
-			// sleep before next batch
-			time.Sleep(5 * time.Second)
+		// init pvr object
+		if err := run.pvr.Init(); err != nil {
+			log.WithError(err).Fatalf("Failed initializing pvr object for: %s", run.name)
 		}
 
-		// search for any leftover items from batching
-		if continueRunning.Load() && len(searchItems) > 0 {
-			// search items
-			log.WithFields(logrus.Fields{
-				"search_items": len(searchItems),
-			}).Info("Searching...")
-
-			searchedItemsCount += len(searchItems)
+		// search for missing media
+		if _, err := run.processWanted("missing"); err != nil {
+			log.WithError(err).Fatal("Failed searching for missing media...")
+		}
 
-			if _, err := searchForItems(searchItems, "missing"); err != nil {
-				log.WithError(err).Error("Failed searching for items...")
-			} else {
-				log.WithFields(logrus.Fields{
-					"searched_items": searchedItemsCount,
-				}).Info("Search complete")
-			}
+		// export dry run batches
+		if err := exportDryRunBatches(run); err != nil {
+			log.WithError(err).Fatal("Failed exporting dry run batches")
 		}
 	},
 }
@@ -184,7 +45,13 @@ func init() {
 	rootCmd.AddCommand(missingCmd)
 
 	missingCmd.Flags().IntVarP(&maxQueueSize, "queue-size", "q", 0, "Exit when queue size reached.")
+	missingCmd.Flags().BoolVar(&flagQueueWait, "queue-wait", false, "Pause instead of stopping when queue size reached.")
+	missingCmd.Flags().IntVar(&flagQueueLow, "queue-low", 0, "Resume a paused search when queue size drops to this (default half of queue-size).")
+	missingCmd.Flags().DurationVar(&flagQueueDeadline, "queue-deadline", 0, "Stop waiting for the queue once searching has run this long.")
 	missingCmd.Flags().IntVarP(&maxSearchItems, "max-search", "m", 0, "Exit when this many items have been searched.")
 	missingCmd.Flags().IntVarP(&searchBatchSize, "search-size", "s", 10, "How many items to search at once.")
+	missingCmd.Flags().StringVarP(&flagSearchOrder, "order", "o", "", "Order to search items in (newest, oldest, least_recent, random, round_robin).")
 	missingCmd.Flags().BoolVarP(&flagRefreshCache, "refresh-cache", "r", false, "Refresh the locally stored cache.")
+	missingCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "Show the items that would be searched without searching them.")
+	missingCmd.Flags().StringVar(&flagDryRunExport, "dry-run-export", "", "Export the batches a dry run would search to this JSON file.")
 }
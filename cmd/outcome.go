@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"math"
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	"github.com/sirupsen/logrus"
+)
+
+/* Private Helpers */
+
+// recordSearchOutcomes stores whether anything was grabbed for each item of a finished search
+func (r *pvrRun) recordSearchOutcomes(cmd *searchCommand, failed bool) {
+	outcomeTime := time.Now().UTC()
+	itemOutcomes := make(map[string][]int)
+
+	if failed {
+		itemOutcomes[database.SearchOutcomeFailed] = pluckMediaItemIds(cmd.items)
+	} else {
+		// look for grabs made since the search was submitted
+		grabbedIds, err := r.pvr.GetGrabbedMediaItems(cmd.submitted)
+		if err != nil {
+			r.log.WithError(err).Warn("Failed retrieving search outcome...")
+			return
+		}
+
+		for _, item := range cmd.items {
+			outcome := database.SearchOutcomeNothingFound
+			if grabbedIds[item.ItemId] {
+				outcome = database.SearchOutcomeGrabbed
+			}
+
+			itemOutcomes[outcome] = append(itemOutcomes[outcome], item.ItemId)
+		}
+	}
+
+	// store outcomes
+	r.outcomesMx.Lock()
+	defer r.outcomesMx.Unlock()
+
+	if r.outcomes[cmd.wantedType] == nil {
+		r.outcomes[cmd.wantedType] = make(map[string]int)
+	}
+
+	for outcome, itemIds := range itemOutcomes {
+		r.outcomes[cmd.wantedType][outcome] += len(itemIds)
+
+		if err := database.SetSearchOutcomes(r.lowerName, cmd.wantedType, itemIds, outcome, outcomeTime); err != nil {
+			r.log.WithError(err).Error("Failed storing search outcomes in database")
+		}
+	}
+}
+
+// searchOutcomes returns the number of items grabbed, found nothing and failed for the wanted type ("" for all)
+func (r *pvrRun) searchOutcomes(wantedType string) (int, int, int) {
+	r.outcomesMx.Lock()
+	defer r.outcomesMx.Unlock()
+
+	grabbed, nothingFound, failed := 0, 0, 0
+	for outcomeType, outcomes := range r.outcomes {
+		if wantedType != "" && outcomeType != wantedType {
+			continue
+		}
+
+		grabbed += outcomes[database.SearchOutcomeGrabbed]
+		nothingFound += outcomes[database.SearchOutcomeNothingFound]
+		failed += outcomes[database.SearchOutcomeFailed]
+	}
+
+	return grabbed, nothingFound, failed
+}
+
+// searchOutcomeFields returns the search outcomes of the wanted type ("" for all) as log fields
+func (r *pvrRun) searchOutcomeFields(wantedType string) logrus.Fields {
+	grabbed, nothingFound, failed := r.searchOutcomes(wantedType)
+
+	hitRate := 0.0
+	if total := grabbed + nothingFound + failed; total > 0 {
+		hitRate = math.Round(float64(grabbed)/float64(total)*1000) / 10
+	}
+
+	return logrus.Fields{
+		"grabbed":       grabbed,
+		"nothing_found": nothingFound,
+		"failed":        failed,
+		"hit_rate":      hitRate, // percentage
+	}
+}
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	flagQueueWait     = false
+	flagQueueLow      = 0
+	flagQueueDeadline = time.Duration(0)
+)
+
+/* Private Helpers */
+
+func queueLowWatermark() int {
+	if flagQueueLow > 0 && flagQueueLow < maxQueueSize {
+		return flagQueueLow
+	}
+
+	return maxQueueSize / 2
+}
+
+func (r *pvrRun) startQueueMonitor() chan struct{} {
+	done := make(chan struct{})
+	r.searchPaused.Store(false)
+
+	// dry runs submit nothing, so never pause or abort on queue size
+	if maxQueueSize <= 0 || flagDryRun {
+		return done
+	}
+
+	go func() {
+		r.log.Info("Started queue monitor")
+		for {
+			// retrieve queue size
+			qs, err := r.pvr.GetQueueSize()
+			if err != nil {
+				r.log.WithError(err).Error("Failed retrieving queue size, aborting...")
+				r.continueRunning.Store(false)
+				break
+			}
+
+			// check queue size
+			if !flagQueueWait && qs >= maxQueueSize {
+				r.log.Warnf("Queue size has been reached, aborting....")
+				r.continueRunning.Store(false)
+				break
+			}
+
+			if flagQueueWait && !r.searchPaused.Load() && qs >= maxQueueSize {
+				r.log.WithField("queue_size", qs).Warn("Queue size has been reached, pausing searches...")
+				r.searchPaused.Store(true)
+			} else if flagQueueWait && r.searchPaused.Load() && qs <= queueLowWatermark() {
+				r.log.WithField("queue_size", qs).Info("Queue has drained, resuming searches...")
+				r.searchPaused.Store(false)
+			}
+
+			// sleep before check (or finish when the search has ended)
+			select {
+			case <-done:
+				r.log.Info("Finished queue monitor")
+				return
+			case <-time.After(10 * time.Second):
+			}
+		}
+		r.log.Info("Finished queue monitor")
+	}()
+
+	return done
+}
+
+// waitForQueue blocks while searching is paused by the queue monitor, returning false when the run should stop
+func (r *pvrRun) waitForQueue(searchStarted time.Time) bool {
+	for r.running() && r.searchPaused.Load() {
+		// give up when the deadline has passed
+		if flagQueueDeadline > 0 && time.Since(searchStarted) >= flagQueueDeadline {
+			r.log.WithFields(logrus.Fields{
+				"deadline": flagQueueDeadline,
+			}).Warn("Deadline reached while waiting for the queue to drain, aborting...")
+			r.continueRunning.Store(false)
+			break
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return r.running()
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+)
+
+/* Test Queue Low Watermark */
+
+func TestQueueLowWatermark(t *testing.T) {
+	defer func(queueSize int, queueLow int) {
+		maxQueueSize, flagQueueLow = queueSize, queueLow
+	}(maxQueueSize, flagQueueLow)
+
+	tests := []struct {
+		queueSize int
+		queueLow  int
+		watermark int
+	}{
+		{50, 0, 25},
+		{51, 0, 25},
+		{50, 10, 10},
+		{50, 49, 49},
+		{50, 50, 25},
+		{50, 80, 25},
+		{50, -5, 25},
+		{0, 0, 0},
+	}
+
+	for _, tc := range tests {
+		maxQueueSize, flagQueueLow = tc.queueSize, tc.queueLow
+
+		if got := queueLowWatermark(); got != tc.watermark {
+			t.Errorf("Expected watermark %d for queue size %d and queue low %d but got %d", tc.watermark,
+				tc.queueSize, tc.queueLow, got)
+		}
+	}
+}
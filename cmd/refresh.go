@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	refreshDefaultFullResync = 24 * time.Hour
+	// changes are retrieved from slightly before the last refresh in case the pvr clock differs
+	refreshOverlap = time.Minute
+)
+
+/* Private Helpers */
+
+// refreshChangedItems updates only the media items imported or deleted since the last refresh, returning false
+// when a full refresh is required instead
+func (r *pvrRun) refreshChangedItems(wantedType string, syncStarted time.Time) (bool, error) {
+	state, err := database.GetSyncState(r.lowerName, wantedType)
+	if err != nil {
+		return false, err
+	} else if state == nil || state.LastFullSyncDateUtc.IsZero() {
+		r.log.Debug("No previous full refresh of media items found")
+		return false, nil
+	}
+
+	// run a full refresh every interval as a safety net
+	fullResync := r.cfg.CacheRefresh.FullResync
+	if fullResync <= 0 {
+		fullResync = refreshDefaultFullResync
+	}
+
+	if syncStarted.Sub(state.LastFullSyncDateUtc) >= fullResync {
+		r.log.WithField("last_full_refresh", state.LastFullSyncDateUtc.Format(time.RFC3339)).
+			Info("Full refresh of media items is due")
+		return false, nil
+	}
+
+	// retrieve changes since the last refresh
+	changes, syncStarted, err := r.wantedChanges(state.LastSyncDateUtc.Add(-refreshOverlap), syncStarted)
+	if err != nil && errors.Cause(err) == pvrObj.ErrChangesUnsupported {
+		r.log.Debugf("Incremental refresh not supported by %s", r.cfg.Type)
+		return false, nil
+	} else if err != nil {
+		return false, errors.WithMessage(err, "failed retrieving changed media items")
+	} else if containsExact(wantedType, changes.Unsupported) {
+		r.log.Debugf("Incremental refresh of %s media not possible with %s", wantedDescription(wantedType),
+			r.cfg.Type)
+		return false, nil
+	}
+
+	wantedItems := changes.Missing
+	if wantedType == "cutoff" {
+		wantedItems = changes.Cutoff
+	}
+
+	// stash changed media that is still wanted
+	if err := database.SetMediaItems(r.lowerName, wantedType, wantedItems); err != nil {
+		return false, errors.WithMessage(err, "failed stashing media items in database")
+	}
+
+	// remove changed media no longer wanted
+	wantedIds := make(map[int]bool)
+	for _, item := range wantedItems {
+		wantedIds[item.ItemId] = true
+	}
+
+	var removedIds []int
+	for _, itemId := range changes.ChangedIds {
+		if !wantedIds[itemId] {
+			removedIds = append(removedIds, itemId)
+		}
+	}
+
+	removedItems, err := database.DeleteMediaItems(r.lowerName, wantedType, removedIds)
+	if err != nil {
+		return false, errors.WithMessage(err, "failed removing media items from database")
+	}
+
+	r.log.WithFields(logrus.Fields{
+		"changed_items": len(changes.ChangedIds),
+		"updated_items": len(wantedItems),
+		"removed_items": removedItems,
+	}).Infof("Refreshed changed %s media items", wantedDescription(wantedType))
+
+	// record refresh
+	if err := database.SetSyncState(r.lowerName, wantedType, syncStarted, false); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// wantedChanges retrieves the changes since the time once per run, sharing them between the missing and cutoff
+// refreshes, and returns when the shared changes were retrieved (recorded as the refresh time)
+func (r *pvrRun) wantedChanges(since time.Time, syncStarted time.Time) (*pvrObj.WantedChanges, time.Time, error) {
+	// changes retrieved from an earlier time include every change since this time
+	if r.changes != nil && !r.changesSince.After(since) {
+		return r.changes, r.changesStarted, nil
+	}
+
+	changes, err := r.pvr.GetWantedChanges(since)
+	if err != nil {
+		return nil, syncStarted, err
+	}
+
+	r.changes, r.changesSince, r.changesStarted = changes, since, syncStarted
+	return changes, syncStarted, nil
+}
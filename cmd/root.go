@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"strings"
 	"time"
 
 	"github.com/migz93/wantarr/build"
@@ -28,12 +27,9 @@ var (
 	flagDatabaseFile = "vault.db"
 	flagLogFile      = "activity.log"
 	flagRefreshCache = false
+	flagSearchOrder  = ""
 
 	// Global vars
-	pvrName         string
-	lowerPvrName    string
-	pvrConfig       *config.Pvr
-	pvr             pvrObj.Interface
 	log             *logrus.Entry
 	continueRunning *atomic.Bool
 
@@ -69,6 +65,8 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&flagDatabaseFile, "database", "d", flagDatabaseFile, "Database file")
 	rootCmd.PersistentFlags().StringVarP(&flagLogFile, "log", "l", flagLogFile, "Log file")
 	rootCmd.PersistentFlags().CountVarP(&flagLogLevel, "verbose", "v", "Verbose level")
+	rootCmd.PersistentFlags().DurationVar(&flagShutdownTimeout, "shutdown-timeout", flagShutdownTimeout,
+		"How long to wait for in-flight searches when shutting down")
 
 }
 
@@ -100,31 +98,26 @@ func initConfig() {
 		log.WithError(err).Fatal("Failed to initialize config")
 	}
 
+	// Init Pvr Backends
+	if err := pvrObj.RegisterBackends(config.Config.Backends); err != nil {
+		log.WithError(err).Fatal("Failed to register pvr backends")
+	}
+
 	// Init Globals
 	continueRunning = atomic.NewBool(true)
+
+	// Init Pvr Version Cache
+	pvrObj.SetVersionCache(database.VersionCache{})
+
+	// Init Signal Handler
+	startSignalHandler()
 }
 
 /* Private Helpers */
 
-func parseValidateInputs(args []string) error {
+func parseValidateInputs(args []string) (*pvrRun, error) {
 	// validate pvr exists in config
-	pvrName = args[0]
-	lowerPvrName = strings.ToLower(pvrName)
-	pc, ok := config.Config.Pvr[pvrName]
-	if !ok {
-		return fmt.Errorf("no pvr configuration found for: %q", pvrName)
-	}
-
-	pvrConfig = pc
-
-	// init pvrObj
-	p, err := pvrObj.Get(pvrName, pvrConfig.Type, pvrConfig)
-	if err != nil {
-		return errors.WithMessage(err, "failed loading pvr object")
-	}
-
-	pvr = p
-	return nil
+	return loadPvrRun(args[0])
 }
 
 func pluckMediaItemIds(mediaItems []pvrObj.MediaItem) []int {
@@ -137,26 +130,46 @@ func pluckMediaItemIds(mediaItems []pvrObj.MediaItem) []int {
 	return mediaItemIds
 }
 
-func searchForItems(searchItems []pvrObj.MediaItem, wantedType string) (bool, error) {
-	// set variables required for search
+func (r *pvrRun) searchForItems(searchItems []pvrObj.MediaItem, wantedType string) error {
 	searchItemIds := pluckMediaItemIds(searchItems)
+
+	return r.sendSearch(searchItems, wantedType, "", func() (int, error) {
+		return r.pvr.SearchMediaItems(searchItemIds)
+	})
+}
+
+func (r *pvrRun) searchForGroup(groupSearcher pvrObj.GroupSearcher, group pvrObj.SearchGroup,
+	groupItems []pvrObj.MediaItem, wantedType string) error {
+	return r.sendSearch(groupItems, wantedType, group.Name, func() (int, error) {
+		return groupSearcher.SearchMediaGroup(group)
+	})
+}
+
+func (r *pvrRun) sendSearch(searchItems []pvrObj.MediaItem, wantedType string, searchGroup string,
+	search func() (int, error)) error {
+	// record the batch instead of searching when this is a dry run
+	if flagDryRun {
+		r.recordDryRunBatch(searchItems, wantedType, searchGroup)
+		return nil
+	}
+
+	// set variables required for search
 	searchTime := time.Now().UTC()
 
-	ok, err := pvr.SearchMediaItems(searchItemIds)
-	if err != nil {
-		return false, err
-	} else if !ok {
-		return false, errors.New("failed unexpectedly searching for items")
-	} else {
-		// update search items lastsearch time
-		for pos := range searchItems {
-			(&searchItems[pos]).LastSearch = searchTime
-		}
-
-		if err := database.SetMediaItems(lowerPvrName, wantedType, searchItems); err != nil {
-			log.WithError(err).Fatal("Failed updating search items in database")
-		}
+	// update search items lastsearch time (persisted once the search completes, or on shutdown)
+	for pos := range searchItems {
+		(&searchItems[pos]).LastSearch = searchTime
+	}
+
+	// submit search, the tracker records the items once the search completes
+	if err := r.tracker.submit(wantedType, searchItems, search); err != nil {
+		return errors.WithMessage(err, "failed submitting search")
 	}
 
-	return true, nil
+	// count the search against the budget once it has been sent
+	if err := database.AddSearchLog(r.lowerName, wantedType, len(searchItems), searchTime); err != nil {
+		r.log.WithError(err).Error("Failed recording search against budget...")
+	}
+
+	return nil
 }
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/migz93/wantarr/config"
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/migz93/wantarr/utils/expr"
+	"github.com/migz93/wantarr/utils/timewindow"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.uber.org/atomic"
+)
+
+var (
+	flagMaxParallel = 0
+
+	// limits how many pvrs are searched at once (nil when unlimited)
+	pvrSlots chan struct{}
+)
+
+/* Structs */
+
+// pvrRun holds the state of a search run against a single pvr
+type pvrRun struct {
+	name            string
+	lowerName       string
+	cfg             *config.Pvr
+	pvr             pvrObj.Interface
+	log             *logrus.Entry
+	continueRunning *atomic.Bool
+	searchPaused    *atomic.Bool
+
+	searchWindows  []timewindow.Window
+	searchLocation *time.Location
+	selectRules    []*expr.Expression
+
+	searchedItems map[string]int
+	dryRunBatches []dryRunBatch
+
+	// wanted changes retrieved by this run, shared by its missing and cutoff refreshes
+	changes        *pvrObj.WantedChanges
+	changesSince   time.Time
+	changesStarted time.Time
+
+	tracker    *commandTracker
+	outcomesMx sync.Mutex
+	outcomes   map[string]map[string]int
+}
+
+/* Initializer */
+
+func newPvrRun(name string, pc *config.Pvr, p pvrObj.Interface) *pvrRun {
+	r := &pvrRun{
+		name:            name,
+		lowerName:       strings.ToLower(name),
+		cfg:             pc,
+		pvr:             p,
+		log:             log.WithField("pvr", name),
+		continueRunning: atomic.NewBool(true),
+		searchPaused:    atomic.NewBool(false),
+		searchedItems:   make(map[string]int),
+		outcomes:        make(map[string]map[string]int),
+	}
+
+	r.tracker = newCommandTracker(r)
+	return r
+}
+
+/* Private Helpers */
+
+func initPvrSlots() {
+	limit := flagMaxParallel
+	if limit <= 0 {
+		limit = config.Config.Core.MaxParallelPvrs
+	}
+
+	if limit > 0 {
+		pvrSlots = make(chan struct{}, limit)
+	}
+}
+
+func acquirePvrSlot() {
+	if pvrSlots != nil {
+		pvrSlots <- struct{}{}
+	}
+}
+
+func releasePvrSlot() {
+	if pvrSlots != nil {
+		<-pvrSlots
+	}
+}
+
+func loadPvr(name string) (*config.Pvr, pvrObj.Interface, error) {
+	// validate pvr exists in config
+	pc, ok := config.Config.Pvr[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("no pvr configuration found for: %q", name)
+	}
+
+	// init pvrObj
+	p, err := pvrObj.Get(name, pc.Type, pc)
+	if err != nil {
+		return nil, nil, errors.WithMessage(err, "failed loading pvr object")
+	}
+
+	return pc, p, nil
+}
+
+func loadPvrRun(name string) (*pvrRun, error) {
+	pc, p, err := loadPvr(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPvrRun(name, pc, p), nil
+}
+
+// running returns false once this run, or the whole process, has been asked to stop
+func (r *pvrRun) running() bool {
+	return continueRunning.Load() && r.continueRunning.Load()
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	"github.com/migz93/wantarr/utils/expr"
+	"github.com/pkg/errors"
+)
+
+var (
+	// fields available to select rules
+	selectFields = []string{
+		"id", "title", "parent_id", "parent_title", "season_number", "quality_profile_id", "quality_profile", "tags",
+		"tag_labels", "monitored", "series_type", "wanted_type", "air_date", "air_age", "last_search",
+		"last_search_age", "searched", "search_attempts", "first_search", "now",
+	}
+)
+
+/* Private Helpers */
+
+func (r *pvrRun) loadSelectRules() error {
+	r.selectRules = nil
+
+	for _, rule := range r.cfg.Select {
+		e, err := expr.Compile(rule)
+		if err != nil {
+			return errors.WithMessagef(err, "failed parsing select rule: %q", rule)
+		}
+
+		// validate fields
+		for _, name := range e.Identifiers() {
+			if !containsExact(name, selectFields) {
+				return fmt.Errorf("unknown field %q in select rule: %q", name, rule)
+			}
+		}
+
+		r.selectRules = append(r.selectRules, e)
+	}
+
+	return nil
+}
+
+// selectItem returns whether the media item should be searched, and the reason when it should not
+func (r *pvrRun) selectItem(wantedType string, item database.MediaItem, now time.Time) (bool, string) {
+	// dont search this item if we already searched it within N days
+	if item.LastSearchDateUtc != nil && !item.LastSearchDateUtc.IsZero() {
+		retryDaysAge := r.retryDaysAge(wantedType, item.SearchAttempts)
+		retryAfterDate := item.LastSearchDateUtc.Add((24 * time.Hour) * retryDaysAge)
+		if now.Before(retryAfterDate) {
+			return false, fmt.Sprintf("retry date not reached (%s)", retryAfterDate.Format(time.RFC3339))
+		}
+	}
+
+	// every select rule must match
+	if len(r.selectRules) == 0 {
+		return true, ""
+	}
+
+	env := selectEnv(wantedType, item, now)
+	for _, rule := range r.selectRules {
+		matched, err := rule.EvalBool(env)
+		if err != nil {
+			return false, fmt.Sprintf("select rule failed: %s (%v)", rule, err)
+		} else if !matched {
+			return false, fmt.Sprintf("select rule did not match: %s", rule)
+		}
+	}
+
+	return true, ""
+}
+
+func selectEnv(wantedType string, item database.MediaItem, now time.Time) map[string]interface{} {
+	lastSearch := time.Time{}
+	lastSearchAge := time.Duration(math.MaxInt64)
+	if item.LastSearchDateUtc != nil && !item.LastSearchDateUtc.IsZero() {
+		lastSearch = *item.LastSearchDateUtc
+		lastSearchAge = now.Sub(lastSearch)
+	}
+
+	firstSearch := time.Time{}
+	if item.FirstSearchDateUtc != nil {
+		firstSearch = *item.FirstSearchDateUtc
+	}
+
+	return map[string]interface{}{
+		"id":                 item.Id,
+		"title":              item.Title,
+		"parent_id":          item.ParentId,
+		"parent_title":       item.ParentTitle,
+		"season_number":      item.SeasonNumber,
+		"quality_profile_id": item.QualityProfileId,
+		"quality_profile":    item.QualityProfile,
+		"tags":               item.TagIds(),
+		"tag_labels":         item.Labels(),
+		"monitored":          item.Monitored,
+		"series_type":        item.SeriesType,
+		"wanted_type":        wantedType,
+		"air_date":           item.AirDateUtc,
+		"air_age":            now.Sub(item.AirDateUtc),
+		"last_search":        lastSearch,
+		"last_search_age":    lastSearchAge,
+		"searched":           !lastSearch.IsZero(),
+		"search_attempts":    item.SearchAttempts,
+		"first_search":       firstSearch,
+		"now":                now,
+	}
+}
+
+// containsExact returns whether the list contains the value, matching case
+func containsExact(value string, list []string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/database"
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Search for wanted media files on a schedule",
+	Long:  `This command can be used to search for missing and cutoff unmet media files using the schedules set for each pvr.`,
+
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		// load database
+		if err := database.Init(flagDatabaseFile); err != nil {
+			log.WithError(err).Fatal("Failed opening database file")
+		}
+		defer database.Close()
+
+		// init limits
+		initPvrSlots()
+
+		// init scheduler
+		scheduler := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.PrintfLogger(log))))
+
+		// schedule pvr jobs
+		pvrNames := make([]string, 0, len(config.Config.Pvr))
+		for name := range config.Config.Pvr {
+			pvrNames = append(pvrNames, name)
+		}
+		sort.Strings(pvrNames)
+
+		scheduledJobs := 0
+		for _, name := range pvrNames {
+			pc := config.Config.Pvr[name]
+			if pc.Schedule.Missing == "" && pc.Schedule.Cutoff == "" {
+				log.Debugf("Skipping pvr with no schedule: %s", name)
+				continue
+			}
+
+			// init pvr object, failures are retried by each scheduled search
+			sp := &servePvr{name: name, pc: pc}
+			if err := sp.init(); err != nil {
+				log.WithError(err).Errorf("Failed initializing pvr object for: %s", name)
+			}
+
+			schedules := map[string]string{
+				"missing": pc.Schedule.Missing,
+				"cutoff":  pc.Schedule.Cutoff,
+			}
+
+			for _, wantedType := range []string{"missing", "cutoff"} {
+				if schedules[wantedType] == "" {
+					continue
+				}
+
+				if _, err := scheduler.AddJob(schedules[wantedType], newServeJob(sp, wantedType)); err != nil {
+					log.WithError(err).Fatalf("Failed scheduling %s search for: %s", wantedType, name)
+				}
+
+				log.WithFields(logrus.Fields{
+					"pvr":         name,
+					"wanted_type": wantedType,
+					"schedule":    schedules[wantedType],
+				}).Info("Scheduled search")
+				scheduledJobs++
+			}
+		}
+
+		if scheduledJobs == 0 {
+			log.Fatal("No pvr schedules have been configured")
+		}
+
+		// start scheduler
+		scheduler.Start()
+		log.Info("Started scheduler")
+
+		// wait for shutdown signal
+		<-shutdownRequested
+
+		// stop scheduler
+		log.Info("Stopping scheduler, waiting for running searches to finish...")
+		<-scheduler.Stop().Done()
+		log.Info("Stopped scheduler")
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.Flags().IntVar(&flagMaxParallel, "max-parallel", 0, "How many pvrs to search at the same time (default core.max_parallel_pvrs, 0 is unlimited).")
+	serveCmd.Flags().IntVarP(&maxQueueSize, "queue-size", "q", 0, "Stop a search when queue size reached.")
+	serveCmd.Flags().BoolVar(&flagQueueWait, "queue-wait", false, "Pause instead of stopping when queue size reached.")
+	serveCmd.Flags().IntVar(&flagQueueLow, "queue-low", 0, "Resume a paused search when queue size drops to this (default half of queue-size).")
+	serveCmd.Flags().DurationVar(&flagQueueDeadline, "queue-deadline", 0, "Stop waiting for the queue once searching has run this long.")
+	serveCmd.Flags().IntVarP(&maxSearchItems, "max-search", "m", 0, "Stop a search when this many items have been searched.")
+	serveCmd.Flags().IntVarP(&searchBatchSize, "search-size", "s", 10, "How many items to search at once.")
+	serveCmd.Flags().StringVarP(&flagSearchOrder, "order", "o", "", "Order to search items in (newest, oldest, least_recent, random, round_robin).")
+	serveCmd.Flags().BoolVarP(&flagRefreshCache, "refresh-cache", "r", false, "Refresh the locally stored cache before each search.")
+}
+
+/* Private Helpers */
+
+// servePvr is the pvr shared by the scheduled searches of a pvr, searches for the same pvr are run one at a time
+type servePvr struct {
+	sync.Mutex
+	name string
+	pc   *config.Pvr
+	p    pvrObj.Interface
+}
+
+// init loads the pvr object when not yet loaded and initializes it, detecting the pvr version again when it changed
+func (sp *servePvr) init() error {
+	if sp.p == nil {
+		_, p, err := loadPvr(sp.name)
+		if err != nil {
+			return err
+		}
+		sp.p = p
+	}
+
+	return sp.p.Init()
+}
+
+func newServeJob(sp *servePvr, wantedType string) cron.Job {
+	name := sp.name
+
+	return cron.FuncJob(func() {
+		sp.Lock()
+		defer sp.Unlock()
+
+		acquirePvrSlot()
+		defer releasePvrSlot()
+
+		// skip searches that were waiting when shutdown was requested
+		if !continueRunning.Load() {
+			return
+		}
+
+		// init pvr object
+		if err := sp.init(); err != nil {
+			log.WithError(err).Errorf("Failed initializing pvr object for: %s", name)
+			return
+		}
+
+		run := newPvrRun(name, sp.pc, sp.p)
+
+		log.WithFields(logrus.Fields{
+			"pvr":         name,
+			"wanted_type": wantedType,
+		}).Info("Starting scheduled search")
+
+		// search for wanted media
+		searchedItems, err := run.processWanted(wantedType)
+		if err != nil {
+			log.WithError(err).Errorf("Failed searching for %s media from: %s", wantedDescription(wantedType), name)
+			return
+		}
+
+		log.WithFields(logrus.Fields{
+			"pvr":            name,
+			"wanted_type":    wantedType,
+			"searched_items": searchedItems,
+		}).Info("Finished scheduled search")
+	})
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/migz93/wantarr/database"
+)
+
+var (
+	flagShutdownTimeout = 2 * time.Minute
+
+	// closed when the first shutdown signal is received
+	shutdownRequested = make(chan struct{})
+
+	activeRuns   = make(map[*pvrRun]struct{})
+	activeRunsMx sync.Mutex
+)
+
+/* Private Helpers */
+
+func startSignalHandler() {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		// first signal, stop searching once the in-flight batches have finished
+		sig := <-signals
+		log.WithField("signal", sig).
+			Warnf("Shutting down once in-flight searches finish (timeout %s), send again to force...",
+				flagShutdownTimeout)
+
+		continueRunning.Store(false)
+		close(shutdownRequested)
+
+		// second signal or timeout, force exit
+		select {
+		case sig = <-signals:
+			log.WithField("signal", sig).Warn("Forcing shutdown...")
+		case <-time.After(flagShutdownTimeout):
+			log.Warn("Timed out waiting for in-flight searches to finish, forcing shutdown...")
+		}
+
+		persistInflightSearches()
+		os.Exit(1)
+	}()
+}
+
+func registerRun(r *pvrRun) {
+	activeRunsMx.Lock()
+	defer activeRunsMx.Unlock()
+
+	activeRuns[r] = struct{}{}
+}
+
+func unregisterRun(r *pvrRun) {
+	activeRunsMx.Lock()
+	defer activeRunsMx.Unlock()
+
+	delete(activeRuns, r)
+}
+
+func persistInflightSearches() {
+	activeRunsMx.Lock()
+	defer activeRunsMx.Unlock()
+
+	for r := range activeRuns {
+		for _, cmd := range r.tracker.take() {
+			if err := database.SetMediaItems(r.lowerName, cmd.wantedType, cmd.items); err != nil {
+				r.log.WithError(err).Error("Failed updating in-flight search items in database")
+				continue
+			}
+
+			r.log.WithField("search_items", len(cmd.items)).Info("Updated in-flight search items in database")
+		}
+	}
+
+	database.Close()
+}
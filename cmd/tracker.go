@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"sync"
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	trackerDefaultMaxInflight  = 3
+	trackerDefaultPollInterval = 10 * time.Second
+	// consecutive status errors tolerated before a search is no longer tracked
+	trackerMaxPollErrors = 3
+)
+
+/* Structs */
+
+// searchCommand is a search that has been (or is being) submitted to the pvr
+type searchCommand struct {
+	id         int
+	wantedType string
+	items      []pvrObj.MediaItem
+	submitted  time.Time
+	pollErrors int
+}
+
+// commandTracker watches the search commands submitted to a pvr, recording the searched items once each completes
+type commandTracker struct {
+	r            *pvrRun
+	pollInterval time.Duration
+	slots        chan struct{}
+
+	mx       sync.Mutex
+	commands []*searchCommand
+	pending  sync.WaitGroup
+	stop     chan struct{}
+	stopped  chan struct{}
+}
+
+/* Initializer */
+
+func newCommandTracker(r *pvrRun) *commandTracker {
+	maxInflight := r.cfg.Commands.MaxInflight
+	if maxInflight <= 0 {
+		maxInflight = trackerDefaultMaxInflight
+	}
+
+	pollInterval := r.cfg.Commands.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = trackerDefaultPollInterval
+	}
+
+	return &commandTracker{
+		r:            r,
+		pollInterval: pollInterval,
+		slots:        make(chan struct{}, maxInflight),
+	}
+}
+
+/* Private Helpers */
+
+// start begins polling the status of submitted commands
+func (t *commandTracker) start() {
+	t.stop = make(chan struct{})
+	t.stopped = make(chan struct{})
+
+	go func() {
+		defer close(t.stopped)
+
+		ticker := time.NewTicker(t.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+				t.poll()
+			}
+		}
+	}()
+}
+
+// finish waits for the submitted commands to complete and stops polling
+func (t *commandTracker) finish() {
+	t.pending.Wait()
+
+	close(t.stop)
+	<-t.stopped
+}
+
+// submit sends a search once fewer than the max in-flight commands are outstanding
+func (t *commandTracker) submit(wantedType string, searchItems []pvrObj.MediaItem, search func() (int, error)) error {
+	t.slots <- struct{}{}
+
+	cmd := &searchCommand{
+		wantedType: wantedType,
+		items:      searchItems,
+		submitted:  time.Now().UTC(),
+	}
+
+	// track before submitting so the items are persisted if shutdown is forced mid-submission
+	t.mx.Lock()
+	t.commands = append(t.commands, cmd)
+	t.pending.Add(1)
+	t.mx.Unlock()
+
+	id, err := search()
+	if err != nil {
+		t.remove(cmd)
+		return err
+	}
+
+	t.mx.Lock()
+	cmd.id = id
+	t.mx.Unlock()
+
+	t.r.log.WithFields(logrus.Fields{
+		"command_id":   id,
+		"search_items": len(searchItems),
+	}).Debug("Monitoring search status")
+
+	return nil
+}
+
+// untrack stops tracking the command, returning false when it was no longer tracked
+func (t *commandTracker) untrack(cmd *searchCommand) bool {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	for pos, tracked := range t.commands {
+		if tracked != cmd {
+			continue
+		}
+
+		t.commands = append(t.commands[:pos], t.commands[pos+1:]...)
+		<-t.slots
+		return true
+	}
+
+	return false
+}
+
+// remove stops tracking the command without recording its items
+func (t *commandTracker) remove(cmd *searchCommand) {
+	if t.untrack(cmd) {
+		t.pending.Done()
+	}
+}
+
+// fail stops tracking the command, recording its items as failed
+func (t *commandTracker) fail(cmd *searchCommand) {
+	if !t.untrack(cmd) {
+		return
+	}
+
+	t.r.recordSearchOutcomes(cmd, true)
+	t.pending.Done()
+}
+
+// take returns and stops tracking every outstanding command
+func (t *commandTracker) take() []*searchCommand {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	commands := t.commands
+	t.commands = nil
+
+	for range commands {
+		t.pending.Done()
+		<-t.slots
+	}
+
+	return commands
+}
+
+func (t *commandTracker) poll() {
+	// copy the submitted commands
+	t.mx.Lock()
+	var commands []*searchCommand
+	for _, cmd := range t.commands {
+		if cmd.id != 0 {
+			commands = append(commands, cmd)
+		}
+	}
+	t.mx.Unlock()
+
+	for _, cmd := range commands {
+		log := t.r.log.WithField("command_id", cmd.id)
+
+		// retrieve command status
+		status, err := t.r.pvr.GetCommandStatus(cmd.id)
+		if err != nil {
+			if cmd.pollErrors++; cmd.pollErrors < trackerMaxPollErrors {
+				log.WithError(err).Warn("Failed retrieving command status, retrying...")
+				continue
+			}
+
+			log.WithError(err).Error("Failed retrieving command status, no longer tracking search...")
+			t.fail(cmd)
+			continue
+		}
+		cmd.pollErrors = 0
+
+		log.WithField("status", status.Status).Debug("Status retrieved")
+
+		switch status.Status {
+		case "started", "queued":
+			continue
+		case "completed":
+			break
+		case "failed":
+			log.WithField("message", status.Message).Error("Search failed")
+			t.fail(cmd)
+			continue
+		default:
+			log.WithFields(logrus.Fields{
+				"status":  status.Status,
+				"message": status.Message,
+			}).Error("Search failed with unexpected status")
+			t.fail(cmd)
+			continue
+		}
+
+		// record the searched items (unless they were already persisted by a forced shutdown)
+		if !t.untrack(cmd) {
+			continue
+		}
+
+		if err := database.SetMediaItems(t.r.lowerName, cmd.wantedType, cmd.items); err != nil {
+			log.WithError(err).Error("Failed updating search items in database")
+		} else {
+			log.WithField("search_items", len(cmd.items)).Info("Search complete")
+		}
+
+		t.r.recordSearchOutcomes(cmd, false)
+		t.pending.Done()
+	}
+}
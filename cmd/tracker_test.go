@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/database"
+	"github.com/migz93/wantarr/logger"
+	pvrObj "github.com/migz93/wantarr/pvr"
+)
+
+/* Fake Pvr */
+
+// statusPvr returns the command statuses in order ("error" returns an error), repeating the last
+type statusPvr struct {
+	statuses []string
+	polls    int
+}
+
+func (p *statusPvr) Init() error                                   { return nil }
+func (p *statusPvr) GetQueueSize() (int, error)                    { return 0, nil }
+func (p *statusPvr) GetWantedMissing() ([]pvrObj.MediaItem, error) { return nil, nil }
+func (p *statusPvr) GetWantedCutoff() ([]pvrObj.MediaItem, error)  { return nil, nil }
+func (p *statusPvr) SearchMediaItems([]int) (int, error)           { return 1, nil }
+
+func (p *statusPvr) GetCommandStatus(int) (*pvrObj.CommandStatus, error) {
+	status := p.statuses[len(p.statuses)-1]
+	if p.polls < len(p.statuses) {
+		status = p.statuses[p.polls]
+	}
+	p.polls++
+
+	if status == "error" {
+		return nil, errors.New("status unavailable")
+	}
+	return &pvrObj.CommandStatus{Status: status}, nil
+}
+
+func (p *statusPvr) GetGrabbedMediaItems(time.Time) (map[int]bool, error) {
+	return map[int]bool{}, nil
+}
+
+func (p *statusPvr) GetWantedChanges(time.Time) (*pvrObj.WantedChanges, error) {
+	return nil, pvrObj.ErrChangesUnsupported
+}
+
+// openTestDatabase opens an empty database, returning a func that closes and removes it
+func openTestDatabase(t *testing.T) func() {
+	dir, err := ioutil.TempDir("", "wantarr")
+	if err != nil {
+		t.Fatalf("Failed creating database folder: %v", err)
+	}
+
+	if err := database.Init(filepath.Join(dir, "test.db")); err != nil {
+		os.RemoveAll(dir)
+		t.Fatalf("Failed opening database: %v", err)
+	}
+
+	log = logger.GetLogger("test")
+
+	return func() {
+		database.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+/* Test Command Tracker */
+
+func TestCommandTrackerPollErrors(t *testing.T) {
+	// the tracker records outcomes in the database
+	defer openTestDatabase(t)()
+
+	tests := []struct {
+		statuses []string
+		polls    int
+		tracked  bool
+		failed   bool
+	}{
+		{[]string{"completed"}, 1, false, false},
+		{[]string{"started"}, 5, true, false},
+		{[]string{"error", "completed"}, 2, false, false},
+		{[]string{"error", "error", "completed"}, 2, true, false},
+		{[]string{"error", "error", "completed"}, 3, false, false},
+		{[]string{"error"}, trackerMaxPollErrors - 1, true, false},
+		{[]string{"error"}, trackerMaxPollErrors, false, true},
+		{[]string{"error", "error", "started", "error", "error", "completed"}, 6, false, false},
+		{[]string{"error", "error", "started", "error", "error", "error"}, 6, false, true},
+		{[]string{"failed"}, 1, false, true},
+	}
+
+	for _, tc := range tests {
+		p := &statusPvr{statuses: tc.statuses}
+		r := newPvrRun("test", &config.Pvr{}, p)
+
+		items := []pvrObj.MediaItem{{ItemId: 1}, {ItemId: 2}}
+		if err := r.tracker.submit("missing", items, func() (int, error) {
+			return p.SearchMediaItems(pluckMediaItemIds(items))
+		}); err != nil {
+			t.Fatalf("Failed submitting search: %v", err)
+		}
+
+		for poll := 0; poll < tc.polls; poll++ {
+			r.tracker.poll()
+		}
+
+		tracked := len(r.tracker.commands) > 0
+		failed := r.outcomes["missing"][database.SearchOutcomeFailed] > 0
+
+		if tracked != tc.tracked || failed != tc.failed {
+			t.Errorf("Expected tracked %t and failed %t after %d polls of %v but got tracked %t and failed %t",
+				tc.tracked, tc.failed, tc.polls, tc.statuses, tracked, failed)
+		}
+
+		// slots are released once a search is no longer tracked
+		if slots := len(r.tracker.slots); tracked && slots != 1 || !tracked && slots != 0 {
+			t.Errorf("Expected the slot of %v to be released only once untracked but %d slots are taken",
+				tc.statuses, slots)
+		}
+	}
+}
@@ -0,0 +1,388 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/migz93/wantarr/database"
+	pvrObj "github.com/migz93/wantarr/pvr"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/* Private Helpers */
+
+func wantedDescription(wantedType string) string {
+	if wantedType == "cutoff" {
+		return "cutoff unmet"
+	}
+
+	return wantedType
+}
+
+func (r *pvrRun) retryDaysAge(wantedType string, searchAttempts int) time.Duration {
+	retryDaysAge := r.cfg.RetryDaysAge.Missing
+	retryBackoff := r.cfg.RetryBackoff.Missing
+
+	if wantedType == "cutoff" {
+		retryDaysAge = r.cfg.RetryDaysAge.Cutoff
+		retryBackoff = r.cfg.RetryBackoff.Cutoff
+	}
+
+	// no backoff curve, use fixed interval
+	if len(retryBackoff) == 0 {
+		return retryDaysAge
+	}
+
+	// items searched before attempts were tracked count as one attempt
+	if searchAttempts < 1 {
+		searchAttempts = 1
+	}
+
+	// use the interval for this attempt, capped at the last interval
+	if searchAttempts > len(retryBackoff) {
+		return retryBackoff[len(retryBackoff)-1]
+	}
+
+	return retryBackoff[searchAttempts-1]
+}
+
+func (r *pvrRun) searchOrder() string {
+	if flagSearchOrder != "" {
+		return flagSearchOrder
+	}
+
+	if r.cfg.SearchOrder != "" {
+		return r.cfg.SearchOrder
+	}
+
+	return database.SearchOrderNewest
+}
+
+func (r *pvrRun) refreshWantedItems(wantedType string) error {
+	description := wantedDescription(wantedType)
+
+	// retrieve wanted records from pvr and stash in database
+	existingItemsCount := database.GetItemsCount(r.lowerName, wantedType)
+	if !flagRefreshCache && existingItemsCount >= 1 {
+		return nil
+	}
+
+	syncStarted := time.Now().UTC()
+
+	// refresh only the changed media items when possible
+	if existingItemsCount >= 1 && r.cfg.CacheRefresh.Incremental {
+		refreshed, err := r.refreshChangedItems(wantedType, syncStarted)
+		if err != nil {
+			r.log.WithError(err).Warn("Failed incremental refresh of media items, running full refresh...")
+		} else if refreshed {
+			return nil
+		}
+	}
+
+	r.log.Infof("Retrieving %s media from %s: %q", description, r.cfg.Type, r.name)
+
+	var wantedRecords []pvrObj.MediaItem
+	var err error
+
+	if wantedType == "cutoff" {
+		wantedRecords, err = r.pvr.GetWantedCutoff()
+	} else {
+		wantedRecords, err = r.pvr.GetWantedMissing()
+	}
+
+	if err != nil {
+		return errors.WithMessagef(err, "failed retrieving wanted %s pvr items", description)
+	}
+
+	// stash wanted media in database
+	r.log.Debug("Stashing media items in database...")
+
+	if err := database.SetMediaItems(r.lowerName, wantedType, wantedRecords); err != nil {
+		return errors.WithMessage(err, "failed stashing media items in database")
+	}
+
+	r.log.Info("Stashed media items")
+
+	// remove media no longer wanted
+	if existingItemsCount >= 1 {
+		r.log.Debugf("Removing media items from database that are no longer %s...", description)
+
+		removedItems, err := database.DeleteMissingItems(r.lowerName, wantedType, wantedRecords)
+		if err != nil {
+			return errors.WithMessagef(err, "failed removing media items from database that are no longer %s",
+				description)
+		}
+
+		r.log.WithField("removed_items", removedItems).
+			Infof("Removed media items from database that are no longer %s", description)
+	}
+
+	// record full refresh
+	if err := database.SetSyncState(r.lowerName, wantedType, syncStarted, true); err != nil {
+		r.log.WithError(err).Warn("Failed storing sync state...")
+	}
+
+	return nil
+}
+
+func (r *pvrRun) selectItems(wantedType string, mediaItems []database.MediaItem) []pvrObj.MediaItem {
+	var selectedItems []pvrObj.MediaItem
+	now := time.Now().UTC()
+
+	for _, item := range mediaItems {
+		if selected, reason := r.selectItem(wantedType, item, now); !selected {
+			r.log.WithField("search_attempts", item.SearchAttempts).
+				Tracef("Skipping media item %v (%s): %s", item.Id, mediaItemTitle(item), reason)
+			continue
+		}
+
+		selectedItems = append(selectedItems, item.PvrMediaItem())
+	}
+
+	return selectedItems
+}
+
+// groupedItems returns the group items along with the cached media items of the same parent (and season) that were
+// not selected, as the pvr searches those too
+func groupedItems(group pvrObj.SearchGroup, mediaItems []database.MediaItem) []pvrObj.MediaItem {
+	if group.ParentId == 0 {
+		return group.Items
+	}
+
+	groupItems := append([]pvrObj.MediaItem{}, group.Items...)
+	grouped := make(map[int]bool, len(group.Items))
+	for _, item := range group.Items {
+		grouped[item.ItemId] = true
+	}
+
+	for _, item := range mediaItems {
+		if grouped[item.Id] || item.ParentId != group.ParentId {
+			continue
+		} else if group.Name == "season" && item.SeasonNumber != group.SeasonNumber {
+			continue
+		}
+
+		groupItems = append(groupItems, item.PvrMediaItem())
+	}
+
+	return groupItems
+}
+
+func (r *pvrRun) searchWantedItems(wantedType string) (int, error) {
+	// get media items from database
+	searchOrder := r.searchOrder()
+
+	mediaItems, err := database.GetMediaItems(r.lowerName, wantedType, wantedType == "missing", searchOrder)
+	if err != nil {
+		return 0, errors.WithMessage(err, "failed retrieving media items from database")
+	}
+	r.log.WithFields(logrus.Fields{
+		"media_items":  len(mediaItems),
+		"search_order": searchOrder,
+	}).Debug("Retrieved media items from database")
+
+	// start searching
+	selectedItems := r.selectItems(wantedType, mediaItems)
+	searchedItemsCount := 0
+	searchStarted := time.Now()
+
+	defer func() {
+		r.searchedItems[wantedType] += searchedItemsCount
+	}()
+
+	// search groups
+	if groupSearcher, ok := r.pvr.(pvrObj.GroupSearcher); ok && r.cfg.Grouping.Enabled && r.running() {
+		groups, ungroupedItems, err := groupSearcher.GroupMediaItems(selectedItems)
+		if err != nil {
+			return 0, errors.WithMessage(err, "failed grouping media items")
+		}
+		selectedItems = ungroupedItems
+
+		for _, group := range groups {
+			// abort if required (queue monitor will set this)
+			if !r.running() {
+				return searchedItemsCount, nil
+			}
+
+			// wait for a search window and while the queue monitor has paused searching
+			if !r.waitForSearchWindow() || !r.waitForQueue(searchStarted) {
+				return searchedItemsCount, nil
+			}
+
+			// the pvr searches every wanted item of the group, including those not selected
+			groupItems := groupedItems(group, mediaItems)
+
+			// respect search budget (groups are never split)
+			if items, withinBudget := r.limitToBudget(groupItems); !withinBudget {
+				return searchedItemsCount, nil
+			} else if len(items) < len(groupItems) {
+				// smaller groups and ungrouped items may still fit the budget
+				r.log.WithFields(logrus.Fields{
+					"search_group": group.Name,
+					"parent_id":    group.ParentId,
+					"search_items": len(groupItems),
+				}).Warn("Search budget too small for group, skipping...")
+				continue
+			}
+
+			// do search
+			r.log.WithFields(logrus.Fields{
+				"search_group":  group.Name,
+				"parent_id":     group.ParentId,
+				"season_number": group.SeasonNumber,
+				"search_items":  len(groupItems),
+			}).Info("Searching...")
+
+			searchedItemsCount += len(groupItems)
+
+			if err := r.searchForGroup(groupSearcher, group, groupItems, wantedType); err != nil {
+				r.log.WithError(err).Error("Failed searching for group...")
+			} else {
+				r.log.WithFields(logrus.Fields{
+					"searched_items": searchedItemsCount,
+				}).Info("Search submitted")
+			}
+
+			// max search items reached?
+			if maxSearchItems > 0 && searchedItemsCount >= maxSearchItems {
+				r.log.WithField("searched_items", searchedItemsCount).
+					Info("Max search items reached, aborting...")
+				return searchedItemsCount, nil
+			}
+		}
+	}
+
+	// search items in batches
+	var searchItems []pvrObj.MediaItem
+
+	for _, item := range selectedItems {
+		// abort if required (queue monitor will set this)
+		if !r.running() {
+			break
+		}
+
+		// add item to batch
+		searchItems = append(searchItems, item)
+
+		// not enough items batched yet
+		if len(searchItems) < searchBatchSize {
+			continue
+		}
+
+		// wait for a search window and while the queue monitor has paused searching
+		if !r.waitForSearchWindow() || !r.waitForQueue(searchStarted) {
+			break
+		}
+
+		// respect search budget
+		var withinBudget bool
+		if searchItems, withinBudget = r.limitToBudget(searchItems); !withinBudget {
+			break
+		}
+		batchedItemsCount := len(searchItems)
+
+		// do search
+		r.log.WithFields(logrus.Fields{
+			"search_items": batchedItemsCount,
+		}).Info("Searching...")
+
+		searchedItemsCount += batchedItemsCount
+
+		if err := r.searchForItems(searchItems, wantedType); err != nil {
+			r.log.WithError(err).Error("Failed searching for items...")
+		} else {
+			r.log.WithFields(logrus.Fields{
+				"searched_items": searchedItemsCount,
+			}).Info("Search submitted")
+		}
+
+		// reset batch
+		searchItems = []pvrObj.MediaItem{}
+
+		// max search items reached?
+		if maxSearchItems > 0 && searchedItemsCount >= maxSearchItems {
+			r.log.WithField("searched_items", searchedItemsCount).
+				Info("Max search items reached, aborting...")
+			break
+		}
+	}
+
+	// search for any leftover items from batching
+	if len(searchItems) > 0 && r.waitForSearchWindow() && r.waitForQueue(searchStarted) {
+		// respect search budget
+		searchItems, _ = r.limitToBudget(searchItems)
+	}
+
+	if len(searchItems) > 0 && r.running() {
+		// search items
+		r.log.WithFields(logrus.Fields{
+			"search_items": len(searchItems),
+		}).Info("Searching...")
+
+		searchedItemsCount += len(searchItems)
+
+		if err := r.searchForItems(searchItems, wantedType); err != nil {
+			r.log.WithError(err).Error("Failed searching for items...")
+		} else {
+			r.log.WithFields(logrus.Fields{
+				"searched_items": searchedItemsCount,
+			}).Info("Search submitted")
+		}
+	}
+
+	return searchedItemsCount, nil
+}
+
+func (r *pvrRun) processWanted(wantedType string) (int, error) {
+	// reset stop state left by a previous wanted type
+	r.continueRunning.Store(true)
+
+	registerRun(r)
+	defer unregisterRun(r)
+
+	// load search windows and select rules
+	if err := r.loadSearchWindows(); err != nil {
+		return 0, err
+	}
+
+	if err := r.loadSelectRules(); err != nil {
+		return 0, err
+	}
+
+	// show search budget
+	r.logRemainingBudget()
+
+	// refresh cached media items
+	if err := r.refreshWantedItems(wantedType); err != nil {
+		return 0, err
+	}
+
+	// start queue monitor
+	queueMonitor := r.startQueueMonitor()
+	defer close(queueMonitor)
+
+	// search media items, waiting for submitted searches to complete
+	r.tracker.start()
+	searchedItems, err := r.searchWantedItems(wantedType)
+	r.tracker.finish()
+
+	if !flagDryRun {
+		r.log.WithFields(r.searchOutcomeFields(wantedType)).
+			Infof("Search outcomes for %s media", wantedDescription(wantedType))
+	}
+
+	return searchedItems, err
+}
+
+// mediaItemTitle describes a cached media item for logging, e.g. "Series - Episode"
+func mediaItemTitle(item database.MediaItem) string {
+	switch {
+	case item.ParentTitle != "" && item.Title != "":
+		return fmt.Sprintf("%s - %s", item.ParentTitle, item.Title)
+	case item.Title != "":
+		return item.Title
+	default:
+		return item.ParentTitle
+	}
+}
@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/migz93/wantarr/config"
+)
+
+/* Test Retry Days Age */
+
+func TestRetryDaysAge(t *testing.T) {
+	cfg := &config.Pvr{
+		RetryDaysAge: config.RetryDaysAge{Missing: 90, Cutoff: 60},
+		RetryBackoff: config.RetryBackoff{Missing: []time.Duration{1, 3, 7, 30}},
+	}
+	r := &pvrRun{cfg: cfg}
+
+	tests := []struct {
+		wantedType     string
+		searchAttempts int
+		retryDaysAge   time.Duration
+	}{
+		// backoff curve
+		{"missing", -1, 1},
+		{"missing", 0, 1},
+		{"missing", 1, 1},
+		{"missing", 2, 3},
+		{"missing", 4, 30},
+		{"missing", 5, 30},
+		{"missing", 100, 30},
+		// no backoff curve
+		{"cutoff", 0, 60},
+		{"cutoff", 1, 60},
+		{"cutoff", 10, 60},
+	}
+
+	for _, tc := range tests {
+		if got := r.retryDaysAge(tc.wantedType, tc.searchAttempts); got != tc.retryDaysAge {
+			t.Errorf("Expected %d retry days for %s after %d attempts but got %d", tc.retryDaysAge, tc.wantedType,
+				tc.searchAttempts, got)
+		}
+	}
+
+	// cutoff curve is used for cutoff only
+	cfg.RetryBackoff.Cutoff = []time.Duration{14}
+	if got := r.retryDaysAge("cutoff", 3); got != 14 {
+		t.Errorf("Expected 14 retry days for cutoff but got %d", got)
+	}
+	if got := r.retryDaysAge("missing", 3); got != 7 {
+		t.Errorf("Expected 7 retry days for missing but got %d", got)
+	}
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/migz93/wantarr/utils/timewindow"
+	"github.com/pkg/errors"
+)
+
+/* Private Helpers */
+
+func (r *pvrRun) loadSearchWindows() error {
+	r.searchWindows = nil
+	r.searchLocation = time.Local
+
+	if len(r.cfg.SearchWindows) == 0 {
+		return nil
+	}
+
+	// parse windows
+	windows, err := timewindow.ParseList(r.cfg.SearchWindows)
+	if err != nil {
+		return errors.WithMessage(err, "failed parsing search windows")
+	}
+
+	// load timezone
+	if r.cfg.Timezone != "" {
+		loc, err := time.LoadLocation(r.cfg.Timezone)
+		if err != nil {
+			return errors.Wrapf(err, "failed loading timezone: %q", r.cfg.Timezone)
+		}
+
+		r.searchLocation = loc
+	}
+
+	switch r.cfg.OutsideWindow {
+	case "", "wait", "exit":
+		break
+	default:
+		return fmt.Errorf("unsupported outside_window setting: %q", r.cfg.OutsideWindow)
+	}
+
+	r.searchWindows = windows
+	return nil
+}
+
+// waitForSearchWindow blocks until a search window is open, returning false when the run should stop
+func (r *pvrRun) waitForSearchWindow() bool {
+	if len(r.searchWindows) == 0 {
+		return r.running()
+	}
+
+	now := time.Now().In(r.searchLocation)
+	if timewindow.AnyContains(r.searchWindows, now) {
+		return r.running()
+	}
+
+	next := timewindow.NextStart(r.searchWindows, now)
+
+	// stop searching until the next run
+	if r.cfg.OutsideWindow == "exit" {
+		r.log.WithField("next_window", next).Info("Outside of search windows, aborting...")
+		r.continueRunning.Store(false)
+		return false
+	}
+
+	// dry runs show what would be searched without waiting
+	if flagDryRun {
+		r.log.WithField("next_window", next).Info("Dry run, would wait for next search window")
+		return r.running()
+	}
+
+	r.log.WithField("next_window", next).Info("Outside of search windows, waiting...")
+	for r.running() && time.Now().Before(next) {
+		time.Sleep(time.Second)
+	}
+
+	if r.running() {
+		r.log.Info("Search window opened, resuming searches...")
+	}
+
+	return r.running()
+}
@@ -0,0 +1,56 @@
+package config
+
+// Backend describes the api of an arr pvr, letting pvr types be added without code changes
+type Backend struct {
+	Family             string
+	ApiPath            string   `mapstructure:"api_path"`
+	Versions           []string // supported versions (major version or version prefix)
+	Missing            BackendWanted
+	Cutoff             BackendWanted
+	Fields             BackendFields
+	DateFields         []string `mapstructure:"date_fields"`
+	SearchCommand      string   `mapstructure:"search_command"`
+	SearchIdsKey       string   `mapstructure:"search_ids_key"`
+	HistoryIdField     string   `mapstructure:"history_id_field"`
+	QualityProfilePath string   `mapstructure:"quality_profile_path"`
+	Grouping           BackendGrouping
+	Changes            BackendChanges
+}
+
+type BackendWanted struct {
+	Endpoint string
+	Paged    bool
+	Params   map[string]string
+	// record field values that must match for the record to be wanted
+	Match map[string]interface{}
+	// used when the endpoint is not available (e.g. older versions)
+	Fallback *BackendWanted
+}
+
+type BackendFields struct {
+	Id               string
+	Title            string
+	ParentId         string `mapstructure:"parent_id"`
+	ParentTitle      string `mapstructure:"parent_title"`
+	SeasonNumber     string `mapstructure:"season_number"`
+	QualityProfileId string `mapstructure:"quality_profile_id"`
+	Tags             string
+	Monitored        string
+	SeriesType       string `mapstructure:"series_type"`
+}
+
+type BackendGrouping struct {
+	Parent         string
+	ParentCommand  string `mapstructure:"parent_command"`
+	ParentIdKey    string `mapstructure:"parent_id_key"`
+	ParentEndpoint string `mapstructure:"parent_endpoint"`
+	SeasonCommand  string `mapstructure:"season_command"`
+}
+
+type BackendChanges struct {
+	// endpoint returning a single item by id, used to refresh the items changed since the last sync
+	ItemEndpoint string `mapstructure:"item_endpoint"`
+	// item field values that must match for the item to be wanted
+	Missing map[string]interface{}
+	Cutoff  map[string]interface{}
+}
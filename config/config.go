@@ -13,7 +13,14 @@ import (
 )
 
 type Configuration struct {
-	Pvr map[string]*Pvr
+	Core     Core
+	Pvr      map[string]*Pvr
+	Groups   map[string][]string
+	Backends map[string]*Backend
+}
+
+type Core struct {
+	MaxParallelPvrs int `mapstructure:"max_parallel_pvrs"`
 }
 
 /* Vars */
@@ -113,7 +120,7 @@ func setConfigDefaults(check bool) error {
 	added += setConfigDefault("pvr", map[string]Pvr{}, check)
 
 	// core settings
-	//added += setConfigDefault("core.workers", 8, check)
+	added += setConfigDefault("core.max_parallel_pvrs", 0, check)
 
 	// were new settings added?
 	if check && added > 0 {
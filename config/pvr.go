@@ -3,13 +3,65 @@ package config
 import "time"
 
 type Pvr struct {
-	Type         string
-	URL          string
-	ApiKey       string       `mapstructure:"api_key"`
-	RetryDaysAge RetryDaysAge `mapstructure:"retry_days_age"`
+	Type            string
+	URL             string
+	ApiKey          string       `mapstructure:"api_key"`
+	RetryDaysAge    RetryDaysAge `mapstructure:"retry_days_age"`
+	RetryBackoff    RetryBackoff `mapstructure:"retry_backoff"`
+	SearchOrder     string       `mapstructure:"search_order"`
+	SearchWindows   []string     `mapstructure:"search_windows"`
+	Timezone        string
+	OutsideWindow   string `mapstructure:"outside_window"`
+	Budget          Budget
+	Grouping        SearchGrouping `mapstructure:"search_grouping"`
+	IncludeTags     []string       `mapstructure:"include_tags"`
+	ExcludeTags     []string       `mapstructure:"exclude_tags"`
+	IncludeProfiles WantedProfiles `mapstructure:"include_profiles"`
+	ExcludeProfiles WantedProfiles `mapstructure:"exclude_profiles"`
+	SeriesTypes     []string       `mapstructure:"series_types"`
+	Select          []string
+	Commands        CommandTracking `mapstructure:"command_tracking"`
+	CacheRefresh    CacheRefresh    `mapstructure:"cache_refresh"`
+	Schedule        Schedule
 }
 
 type RetryDaysAge struct {
 	Missing time.Duration
 	Cutoff  time.Duration
 }
+
+type RetryBackoff struct {
+	Missing []time.Duration
+	Cutoff  []time.Duration
+}
+
+type WantedProfiles struct {
+	Missing []string
+	Cutoff  []string
+}
+
+type Budget struct {
+	Daily  int
+	Weekly int
+}
+
+type CommandTracking struct {
+	MaxInflight  int           `mapstructure:"max_inflight"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+}
+
+type CacheRefresh struct {
+	Incremental bool
+	FullResync  time.Duration `mapstructure:"full_resync"`
+}
+
+type SearchGrouping struct {
+	Enabled  bool
+	MinShare float64 `mapstructure:"min_share"`
+	MaxItems int     `mapstructure:"max_items"`
+}
+
+type Schedule struct {
+	Missing string
+	Cutoff  string
+}
@@ -1,7 +1,26 @@
 package database
 
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
 func GetItemsCount(pvrName string, wantedType string) int {
 	itemCount := 0
 	db.Model(&MediaItem{}).Where("pvr_name = ? AND wanted_type = ?", pvrName, wantedType).Count(&itemCount)
 	return itemCount
 }
+
+func GetSearchedItemsCount(pvrName string, since time.Time) (int, error) {
+	var result struct {
+		Total int
+	}
+
+	if err := db.Model(&SearchLog{}).Select("COALESCE(SUM(items), 0) AS total").
+		Where("pvr_name = ? AND search_date_utc >= ?", pvrName, since).Scan(&result).Error; err != nil {
+		return 0, errors.Wrap(err, "failed counting searched items")
+	}
+
+	return result.Total, nil
+}
@@ -1,10 +1,13 @@
 package database
 
 import (
+	"time"
+
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/sqlite"
 	"github.com/migz93/wantarr/logger"
 	stringutils "github.com/migz93/wantarr/utils/strings"
+	"github.com/pkg/errors"
 )
 
 var (
@@ -23,13 +26,42 @@ func Init(databaseFilePath string) error {
 		db = dtb
 	}
 
+	// serialize access as sqlite does not support concurrent writers
+	db.DB().SetMaxOpenConns(1)
+
 	// migrate schema
-	db.AutoMigrate(&MediaItem{})
+	if err := migrateSchema(); err != nil {
+		return err
+	}
+
+	// remove search logs older than the longest budget period
+	if _, err := DeleteSearchLogs(time.Now().UTC().Add(-7 * 24 * time.Hour)); err != nil {
+		log.WithError(err).Error("Failed removing old search logs...")
+	}
+
+	return nil
+}
+
+func migrateSchema() error {
+	// add new tables/columns, existing rows and search history are kept
+	if err := db.AutoMigrate(&MediaItem{}, &SearchLog{}, &PvrVersion{}, &SyncState{}).Error; err != nil {
+		return errors.Wrap(err, "failed migrating database schema")
+	}
+
+	// media items cached before metadata was stored were all retrieved as monitored
+	if err := db.Model(&MediaItem{}).Where("monitored IS NULL").
+		UpdateColumn("monitored", true).Error; err != nil {
+		return errors.Wrap(err, "failed migrating media items")
+	}
 
 	return nil
 }
 
 func Close() {
+	if db == nil {
+		return
+	}
+
 	if err := db.Close(); err != nil {
 		log.WithError(err).Error("Failed closing database gracefully...")
 	}
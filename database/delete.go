@@ -1,6 +1,8 @@
 package database
 
 import (
+	"time"
+
 	"github.com/migz93/wantarr/pvr"
 	"github.com/pkg/errors"
 )
@@ -48,3 +50,34 @@ func DeleteMissingItems(pvrName string, wantedType string, newMediaItems []pvr.M
 
 	return removedItems, nil
 }
+
+func DeleteSearchLogs(before time.Time) (int, error) {
+	res := db.Where("search_date_utc < ?", before).Delete(&SearchLog{})
+	if res.Error != nil {
+		return 0, errors.Wrap(res.Error, "failed removing old search logs")
+	}
+
+	return int(res.RowsAffected), nil
+}
+
+func DeleteMediaItems(pvrName string, wantedType string, itemIds []int) (int, error) {
+	removedItems := 0
+
+	// delete in chunks to stay within the sqlite variable limit
+	for start := 0; start < len(itemIds); start += 500 {
+		end := start + 500
+		if end > len(itemIds) {
+			end = len(itemIds)
+		}
+
+		res := db.Where("pvr_name = ? AND wanted_type = ? AND id IN (?)", pvrName, wantedType, itemIds[start:end]).
+			Delete(&MediaItem{})
+		if res.Error != nil {
+			return removedItems, errors.Wrap(res.Error, "failed removing media items")
+		}
+
+		removedItems += int(res.RowsAffected)
+	}
+
+	return removedItems, nil
+}
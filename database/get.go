@@ -1,11 +1,13 @@
 package database
 
 import (
-	"github.com/pkg/errors"
+	"fmt"
 	"time"
+
+	"github.com/pkg/errors"
 )
 
-func GetMediaItems(pvrName string, wantedType string, excludeFuture bool) ([]MediaItem, error) {
+func GetMediaItems(pvrName string, wantedType string, excludeFuture bool, order string) ([]MediaItem, error) {
 	var mediaItems []MediaItem
 
 	// generate query
@@ -20,10 +22,36 @@ func GetMediaItems(pvrName string, wantedType string, excludeFuture bool) ([]Med
 		sqlParams = append(sqlParams, time.Now().UTC())
 	}
 
+	// determine order
+	var sqlOrder []string
+
+	switch order {
+	case "", SearchOrderNewest, SearchOrderRoundRobin:
+		sqlOrder = []string{"air_date_utc desc"}
+	case SearchOrderOldest:
+		sqlOrder = []string{"air_date_utc asc"}
+	case SearchOrderLeastRecent:
+		sqlOrder = []string{"last_search_date_utc asc", "air_date_utc desc"}
+	case SearchOrderRandom:
+		sqlOrder = []string{"RANDOM()"}
+	default:
+		return nil, fmt.Errorf("unsupported search order: %q", order)
+	}
+
 	// exec query
-	if err := db.Where(sqlQuery, sqlParams...).Order("air_date_utc desc").Find(&mediaItems).Error; err != nil {
+	query := db.Where(sqlQuery, sqlParams...)
+	for _, o := range sqlOrder {
+		query = query.Order(o)
+	}
+
+	if err := query.Find(&mediaItems).Error; err != nil {
 		return nil, errors.Wrap(err, "failed querying for media items")
 	}
 
+	// interleave parents
+	if order == SearchOrderRoundRobin {
+		mediaItems = roundRobinMediaItems(mediaItems)
+	}
+
 	return mediaItems, nil
 }
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/migz93/wantarr/pvr"
+)
+
+/* Public */
+
+// PvrMediaItem returns the media item with its cached metadata as a pvr media item
+func (m MediaItem) PvrMediaItem() pvr.MediaItem {
+	return pvr.MediaItem{
+		ItemId:           m.Id,
+		Title:            m.Title,
+		ParentId:         m.ParentId,
+		ParentTitle:      m.ParentTitle,
+		SeasonNumber:     m.SeasonNumber,
+		QualityProfileId: m.QualityProfileId,
+		QualityProfile:   m.QualityProfile,
+		Tags:             m.TagIds(),
+		TagLabels:        m.Labels(),
+		Monitored:        m.Monitored,
+		SeriesType:       m.SeriesType,
+		AirDateUtc:       m.AirDateUtc,
+	}
+}
+
+// TagIds returns the tag ids stored against the media item
+func (m MediaItem) TagIds() []int {
+	return parseTags(m.Tags)
+}
+
+// Labels returns the tag labels stored against the media item
+func (m MediaItem) Labels() []string {
+	var labels []string
+
+	for _, label := range strings.Split(m.TagLabels, ",") {
+		if label = strings.TrimSpace(label); label != "" {
+			labels = append(labels, label)
+		}
+	}
+
+	return labels
+}
+
+/* Private */
+
+func formatTags(tags []int) string {
+	tagIds := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tagIds = append(tagIds, strconv.Itoa(tag))
+	}
+
+	return strings.Join(tagIds, ",")
+}
+
+func parseTags(tags string) []int {
+	var tagIds []int
+
+	for _, tag := range strings.Split(tags, ",") {
+		if tagId, err := strconv.Atoi(strings.TrimSpace(tag)); err == nil {
+			tagIds = append(tagIds, tagId)
+		}
+	}
+
+	return tagIds
+}
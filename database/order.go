@@ -0,0 +1,55 @@
+package database
+
+const (
+	// SearchOrderNewest searches the most recently aired items first
+	SearchOrderNewest = "newest"
+	// SearchOrderOldest searches the least recently aired items first
+	SearchOrderOldest = "oldest"
+	// SearchOrderLeastRecent searches items that have never been searched, or were searched the longest ago, first
+	SearchOrderLeastRecent = "least_recent"
+	// SearchOrderRandom searches items in a random order
+	SearchOrderRandom = "random"
+	// SearchOrderRoundRobin searches the newest item of each series/artist/author in turn
+	SearchOrderRoundRobin = "round_robin"
+)
+
+/* Private */
+
+func roundRobinMediaItems(mediaItems []MediaItem) []MediaItem {
+	// group items by parent, keeping the order parents were first seen in
+	var parents [][]MediaItem
+	parentPos := make(map[int]int)
+
+	for _, item := range mediaItems {
+		// items without a parent are a group of their own
+		if item.ParentId == 0 {
+			parents = append(parents, []MediaItem{item})
+			continue
+		}
+
+		pos, ok := parentPos[item.ParentId]
+		if !ok {
+			pos = len(parents)
+			parentPos[item.ParentId] = pos
+			parents = append(parents, nil)
+		}
+
+		parents[pos] = append(parents[pos], item)
+	}
+
+	// take an item from each parent in turn
+	ordered := make([]MediaItem, 0, len(mediaItems))
+
+	for round := 0; len(parents) > 0; round++ {
+		remaining := parents[:0]
+		for _, items := range parents {
+			ordered = append(ordered, items[round])
+			if round+1 < len(items) {
+				remaining = append(remaining, items)
+			}
+		}
+		parents = remaining
+	}
+
+	return ordered
+}
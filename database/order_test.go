@@ -0,0 +1,41 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+)
+
+/* Test Round Robin Order */
+
+func TestRoundRobinMediaItems(t *testing.T) {
+	// items as id:parent_id, in newest first order
+	tests := []struct {
+		items [][2]int
+		ids   []int
+	}{
+		{nil, []int{}},
+		{[][2]int{{1, 10}}, []int{1}},
+		{[][2]int{{1, 10}, {2, 10}, {3, 10}}, []int{1, 2, 3}},
+		{[][2]int{{1, 10}, {2, 10}, {3, 20}, {4, 20}}, []int{1, 3, 2, 4}},
+		{[][2]int{{1, 10}, {2, 20}, {3, 10}, {4, 10}, {5, 30}}, []int{1, 2, 5, 3, 4}},
+		// items without a parent are each a group of their own
+		{[][2]int{{1, 0}, {2, 10}, {3, 0}, {4, 10}}, []int{1, 2, 3, 4}},
+		{[][2]int{{1, 10}, {2, 10}, {3, 0}, {4, 0}}, []int{1, 3, 4, 2}},
+	}
+
+	for _, tc := range tests {
+		var mediaItems []MediaItem
+		for _, item := range tc.items {
+			mediaItems = append(mediaItems, MediaItem{Id: item[0], ParentId: item[1]})
+		}
+
+		ids := make([]int, 0)
+		for _, item := range roundRobinMediaItems(mediaItems) {
+			ids = append(ids, item.Id)
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint(tc.ids) {
+			t.Errorf("Expected %v from %v but got %v", tc.ids, tc.items, ids)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package database
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	SearchOutcomeGrabbed      = "grabbed"
+	SearchOutcomeNothingFound = "nothing_found"
+	SearchOutcomeFailed       = "failed"
+)
+
+func SetSearchOutcomes(pvrName string, wantedType string, itemIds []int, outcome string, outcomeTime time.Time) error {
+	// update in chunks to stay within the sqlite variable limit
+	for start := 0; start < len(itemIds); start += 500 {
+		end := start + 500
+		if end > len(itemIds) {
+			end = len(itemIds)
+		}
+
+		if err := db.Model(&MediaItem{}).
+			Where("pvr_name = ? AND wanted_type = ? AND id IN (?)", pvrName, wantedType, itemIds[start:end]).
+			UpdateColumns(map[string]interface{}{
+				"search_outcome":          outcome,
+				"search_outcome_date_utc": outcomeTime,
+			}).Error; err != nil {
+			return errors.Wrap(err, "failed updating search outcomes")
+		}
+	}
+
+	return nil
+}
@@ -6,6 +6,45 @@ type MediaItem struct {
 	Id                int    `gorm:"primary_key;auto_increment:false"`
 	PvrName           string `gorm:"primary_key"`
 	WantedType        string `gorm:"primary_key"`
+	Title             string
+	ParentId          int
+	ParentTitle       string
+	SeasonNumber      int
+	QualityProfileId  int
+	QualityProfile    string
+	Tags              string // comma separated tag ids
+	TagLabels         string // comma separated tag labels
+	Monitored         bool
+	SeriesType        string
 	AirDateUtc        time.Time
 	LastSearchDateUtc *time.Time `gorm:"null"`
+	// search attempts are reset when the item leaves the wanted list and is removed
+	SearchAttempts     int
+	FirstSearchDateUtc *time.Time `gorm:"null"`
+	// outcome of the last search (grabbed, nothing_found or failed)
+	SearchOutcome        string
+	SearchOutcomeDateUtc *time.Time `gorm:"null"`
+}
+
+type SearchLog struct {
+	Id            int    `gorm:"primary_key"`
+	PvrName       string `gorm:"index"`
+	WantedType    string
+	Items         int
+	SearchDateUtc time.Time `gorm:"index"`
+}
+
+type PvrVersion struct {
+	PvrName         string `gorm:"primary_key"`
+	Family          string
+	Type            string
+	Version         string
+	DetectedDateUtc time.Time
+}
+
+type SyncState struct {
+	PvrName             string `gorm:"primary_key"`
+	WantedType          string `gorm:"primary_key"`
+	LastSyncDateUtc     time.Time
+	LastFullSyncDateUtc time.Time
 }
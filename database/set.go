@@ -1,6 +1,9 @@
 package database
 
 import (
+	"strings"
+	"time"
+
 	"github.com/migz93/wantarr/pvr"
 	"github.com/pkg/errors"
 )
@@ -11,11 +14,20 @@ func SetMediaItems(pvrName string, wantedType string, mediaItems []pvr.MediaItem
 
 	// bulk insert/update items
 	for _, item := range mediaItems {
-		// set item to insert/update
-		mediaItem := MediaItem{
-			PvrName:    pvrName,
-			WantedType: wantedType,
-			AirDateUtc: item.AirDateUtc,
+		// set item to insert/update (map so that cleared metadata is also updated)
+		var mediaItem MediaItem
+		itemData := map[string]interface{}{
+			"title":              item.Title,
+			"parent_id":          item.ParentId,
+			"parent_title":       item.ParentTitle,
+			"season_number":      item.SeasonNumber,
+			"quality_profile_id": item.QualityProfileId,
+			"quality_profile":    item.QualityProfile,
+			"tags":               formatTags(item.Tags),
+			"tag_labels":         strings.Join(item.TagLabels, ","),
+			"monitored":          item.Monitored,
+			"series_type":        item.SeriesType,
+			"air_date_utc":       item.AirDateUtc,
 		}
 
 		// create item if not exists
@@ -23,7 +35,7 @@ func SetMediaItems(pvrName string, wantedType string, mediaItems []pvr.MediaItem
 			Id:         item.ItemId,
 			PvrName:    pvrName,
 			WantedType: wantedType,
-		}).Assign(mediaItem).FirstOrCreate(&mediaItem).Error
+		}).Assign(itemData).FirstOrCreate(&mediaItem).Error
 
 		if err != nil {
 			log.WithError(err).Errorf("Failed inserting media item: %v", item.ItemId)
@@ -33,6 +45,10 @@ func SetMediaItems(pvrName string, wantedType string, mediaItems []pvr.MediaItem
 		if !item.LastSearch.IsZero() {
 			mediaItem.AirDateUtc = item.AirDateUtc
 			mediaItem.LastSearchDateUtc = &item.LastSearch
+			mediaItem.SearchAttempts += 1
+			if mediaItem.FirstSearchDateUtc == nil {
+				mediaItem.FirstSearchDateUtc = &item.LastSearch
+			}
 
 			if err := tx.Save(&mediaItem).Error; err != nil {
 				log.WithError(err).Errorf("Failed updating media item: %v", item.ItemId)
@@ -48,3 +64,18 @@ func SetMediaItems(pvrName string, wantedType string, mediaItems []pvr.MediaItem
 
 	return nil
 }
+
+func AddSearchLog(pvrName string, wantedType string, items int, searchTime time.Time) error {
+	searchLog := SearchLog{
+		PvrName:       pvrName,
+		WantedType:    wantedType,
+		Items:         items,
+		SearchDateUtc: searchTime,
+	}
+
+	if err := db.Create(&searchLog).Error; err != nil {
+		return errors.Wrap(err, "failed inserting search log")
+	}
+
+	return nil
+}
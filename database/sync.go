@@ -0,0 +1,47 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+/* Public */
+
+// GetSyncState returns when the cached media items were last refreshed, nil when never refreshed
+func GetSyncState(pvrName string, wantedType string) (*SyncState, error) {
+	var state SyncState
+	if err := db.Where("pvr_name = ? AND wanted_type = ?", pvrName, wantedType).First(&state).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed retrieving sync state")
+	}
+
+	return &state, nil
+}
+
+// SetSyncState records a refresh of the cached media items, full refreshes also reset the full sync date
+func SetSyncState(pvrName string, wantedType string, syncTime time.Time, fullSync bool) error {
+	state, err := GetSyncState(pvrName, wantedType)
+	if err != nil {
+		return err
+	} else if state == nil {
+		state = &SyncState{
+			PvrName:    pvrName,
+			WantedType: wantedType,
+		}
+	}
+
+	state.LastSyncDateUtc = syncTime
+	if fullSync {
+		state.LastFullSyncDateUtc = syncTime
+	}
+
+	if err := db.Save(state).Error; err != nil {
+		return errors.Wrap(err, "failed storing sync state")
+	}
+
+	return nil
+}
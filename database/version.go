@@ -0,0 +1,59 @@
+package database
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/pkg/errors"
+)
+
+/* Structs */
+
+// VersionCache implements pvr.VersionCache using the database
+type VersionCache struct{}
+
+/* Public */
+
+func GetPvrType(pvrName string, pvrFamily string) (string, error) {
+	if db == nil {
+		return "", nil
+	}
+
+	var version PvrVersion
+	if err := db.Where("pvr_name = ? AND family = ?", pvrName, pvrFamily).First(&version).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "failed retrieving pvr version")
+	}
+
+	return version.Type, nil
+}
+
+func SetPvrType(pvrName string, pvrFamily string, pvrType string, version string) error {
+	if db == nil {
+		return nil
+	}
+
+	if err := db.Save(&PvrVersion{
+		PvrName:         pvrName,
+		Family:          pvrFamily,
+		Type:            pvrType,
+		Version:         version,
+		DetectedDateUtc: time.Now().UTC(),
+	}).Error; err != nil {
+		return errors.Wrap(err, "failed storing pvr version")
+	}
+
+	return nil
+}
+
+/* Interface Implements */
+
+func (VersionCache) GetPvrType(pvrName string, pvrFamily string) (string, error) {
+	return GetPvrType(pvrName, pvrFamily)
+}
+
+func (VersionCache) SetPvrType(pvrName string, pvrFamily string, pvrType string, version string) error {
+	return SetPvrType(pvrName, pvrFamily, pvrType, version)
+}
@@ -0,0 +1,636 @@
+package pvr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/imroc/req"
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/logger"
+	"github.com/migz93/wantarr/utils/web"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/* Structs */
+
+// Arr is the pvr client shared by every arr, driven by the backend descriptor of the pvr type
+type Arr struct {
+	cfg        *config.Pvr
+	backend    config.Backend
+	kind       string
+	log        *logrus.Entry
+	apiUrl     string
+	reqHeaders req.Header
+	timeout    int
+
+	// tag labels and quality profile names, retrieved again after each init
+	lookupsMx    sync.Mutex
+	lookups      *pvrLookups
+	lookupsStale bool
+}
+
+type ArrSeasonStatistics struct {
+	TotalEpisodeCount int
+}
+
+type ArrSeason struct {
+	SeasonNumber int
+	Statistics   ArrSeasonStatistics
+}
+
+type ArrParent struct {
+	Id      int
+	Seasons []ArrSeason
+}
+
+type ArrCommandResponse struct {
+	Id int
+}
+
+/* Initializer */
+
+func NewArr(name string, pvrType string, backend *config.Backend, c *config.Pvr) *Arr {
+	// set api url
+	apiUrl := ""
+	if strings.Contains(c.URL, "/api") {
+		apiUrl = c.URL
+	} else {
+		apiUrl = web.JoinURL(c.URL, backend.ApiPath)
+	}
+
+	// set headers
+	reqHeaders := req.Header{
+		"X-Api-Key": c.ApiKey,
+	}
+
+	// name used in messages
+	kind := backend.Family
+	if kind == "" {
+		kind = strings.ToLower(pvrType)
+	}
+
+	return &Arr{
+		cfg:        c,
+		backend:    withBackendDefaults(*backend),
+		kind:       kind,
+		log:        logger.GetLogger(name),
+		apiUrl:     apiUrl,
+		reqHeaders: reqHeaders,
+		timeout:    pvrDefaultTimeout,
+	}
+}
+
+/* Private */
+
+func withBackendDefaults(backend config.Backend) config.Backend {
+	if backend.Fields.Id == "" {
+		backend.Fields.Id = "id"
+	}
+	if backend.Fields.Title == "" {
+		backend.Fields.Title = "title"
+	}
+	if backend.Fields.Monitored == "" {
+		backend.Fields.Monitored = "monitored"
+	}
+	if backend.QualityProfilePath == "" {
+		backend.QualityProfilePath = "/qualityprofile"
+	}
+
+	return backend
+}
+
+func (p *Arr) mediaItem(record arrRecord) MediaItem {
+	fields := p.backend.Fields
+
+	return MediaItem{
+		ItemId:           record.int(fields.Id),
+		Title:            record.string(fields.Title),
+		ParentId:         record.int(fields.ParentId),
+		ParentTitle:      record.string(fields.ParentTitle),
+		SeasonNumber:     record.int(fields.SeasonNumber),
+		QualityProfileId: record.int(fields.QualityProfileId),
+		Tags:             record.ints(fields.Tags),
+		Monitored:        record.bool(fields.Monitored),
+		SeriesType:       record.string(fields.SeriesType),
+		AirDateUtc:       record.latestDate(p.backend.DateFields),
+		LastSearch:       time.Time{},
+	}
+}
+
+// getParents retrieves every parent (with its seasons) in a single request
+func (p *Arr) getParents() (map[int]ArrParent, error) {
+	var list []ArrParent
+	if err := getApiList(p.apiUrl, p.backend.Grouping.ParentEndpoint, p.reqHeaders, p.timeout, &list); err != nil {
+		return nil, errors.WithMessagef(err, "failed retrieving %s from %s", p.backend.Grouping.Parent, p.kind)
+	}
+
+	parents := make(map[int]ArrParent, len(list))
+	for _, parent := range list {
+		parents[parent.Id] = parent
+	}
+
+	return parents, nil
+}
+
+func (p *Arr) sendCommand(payload map[string]interface{}) (int, error) {
+	// send request
+	resp, err := web.GetResponse(web.POST, web.JoinURL(p.apiUrl, "/command"), p.timeout, p.reqHeaders,
+		&pvrDefaultRetry, req.BodyJSON(payload))
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed retrieving command api response from %s", p.kind)
+	}
+	defer resp.Response().Body.Close()
+
+	// validate response
+	if resp.Response().StatusCode != 201 {
+		return 0, fmt.Errorf("failed retrieving valid command api response from %s: %s", p.kind,
+			resp.Response().Status)
+	}
+
+	// decode response
+	var q ArrCommandResponse
+	if err := resp.ToJSON(&q); err != nil {
+		return 0, errors.WithMessagef(err, "failed decoding command api response from %s", p.kind)
+	}
+
+	return q.Id, nil
+}
+
+// getWantedItems retrieves the wanted media items of a list endpoint or a single page, with the number of records
+// retrieved and the total records of paged endpoints
+func (p *Arr) getWantedItems(wanted config.BackendWanted, params req.QueryParam) ([]MediaItem, int, int, error) {
+	var mediaItems []MediaItem
+	records := 0
+
+	// process records as they are decoded
+	totalRecords, err := getApiRecords(p.apiUrl, wanted.Endpoint, p.reqHeaders, p.timeout, params,
+		func(record arrRecord) error {
+			records++
+
+			// is this record wanted?
+			if record.matches(wanted.Match) {
+				mediaItems = append(mediaItems, p.mediaItem(record))
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	return mediaItems, records, totalRecords, nil
+}
+
+// getWantedPages retrieves the wanted media items of every page, fetching the pages after the first in parallel
+func (p *Arr) getWantedPages(wanted config.BackendWanted, params req.QueryParam) ([]MediaItem, int, error) {
+	pageParams := func(page int) req.QueryParam {
+		pp := req.QueryParam{"page": page}
+		for key, value := range params {
+			pp[key] = value
+		}
+		return pp
+	}
+
+	// retrieve first page
+	mediaItems, records, totalRecords, err := p.getWantedItems(wanted, pageParams(1))
+	if err != nil {
+		return nil, 0, err
+	}
+	p.log.WithField("page", 1).Debug("Retrieved")
+
+	pages := pageCount(records, totalRecords)
+	if pages <= 1 {
+		return mediaItems, records, nil
+	}
+
+	// retrieve remaining pages
+	pageItems := make([][]MediaItem, pages+1)
+	pageErrs := make([]error, pages+1)
+	pageRecords := make([]int, pages+1)
+
+	fetchParallel(pages-1, func(pos int) {
+		page := pos + 2
+
+		pageItems[page], pageRecords[page], _, pageErrs[page] = p.getWantedItems(wanted, pageParams(page))
+		if pageErrs[page] == nil {
+			p.log.WithField("page", page).Debug("Retrieved")
+		}
+	})
+
+	// merge pages in order
+	for page := 2; page <= pages; page++ {
+		if pageErrs[page] != nil {
+			return nil, 0, errors.WithMessagef(pageErrs[page], "failed retrieving page %d", page)
+		}
+
+		mediaItems = append(mediaItems, pageItems[page]...)
+		records += pageRecords[page]
+	}
+
+	return mediaItems, records, nil
+}
+
+func (p *Arr) getWanted(wantedType string, wanted config.BackendWanted) ([]MediaItem, error) {
+	if wanted.Endpoint == "" {
+		return nil, fmt.Errorf("wanted endpoint not supported by %s", p.kind)
+	}
+
+	// set params
+	params := req.QueryParam{}
+	for key, value := range wanted.Params {
+		params[key] = value
+	}
+
+	// retrieve list or page results
+	var mediaItems []MediaItem
+	var totalRecords int
+	var err error
+
+	if wanted.Paged {
+		params["pageSize"] = pvrDefaultPageSize
+		mediaItems, totalRecords, err = p.getWantedPages(wanted, params)
+	} else {
+		mediaItems, totalRecords, _, err = p.getWantedItems(wanted, params)
+	}
+
+	if err != nil && errors.Cause(err) == errApiNotFound && wanted.Fallback != nil {
+		// endpoint not available in this version
+		p.log.WithError(err).Debugf("Wanted endpoint unavailable, falling back to %s...", wanted.Fallback.Endpoint)
+		return p.getWanted(wantedType, *wanted.Fallback)
+	} else if err != nil {
+		return nil, errors.WithMessagef(err, "failed retrieving wanted media from %s", p.kind)
+	}
+
+	p.log.WithField("media_items", totalRecords).Info("Finished")
+
+	// apply filters
+	lookups, err := p.getLookups()
+	if err != nil {
+		return nil, err
+	}
+
+	return filterMediaItems(p.log, p.cfg, wantedType, lookups, mediaItems)
+}
+
+/* Interface Implements */
+
+func (p *Arr) Init() error {
+	// retrieve system status
+	version, err := getSystemVersion(p.apiUrl, p.cfg.ApiKey)
+	if err != nil {
+		return errors.Wrapf(err, "failed initializing %s pvr", p.kind)
+	}
+
+	// determine version
+	if !versionSupported(version, p.backend.Versions) {
+		return fmt.Errorf("unsupported version of %s pvr: %s", p.kind, version)
+	}
+
+	// retrieve tag labels and quality profile names again for this run
+	p.lookupsMx.Lock()
+	p.lookupsStale = true
+	p.lookupsMx.Unlock()
+	return nil
+}
+
+func (p *Arr) GetQueueSize() (int, error) {
+	// retrieve queue (a list of items, or a page with the total records)
+	var q interface{}
+	if err := getApiList(p.apiUrl, "/queue", p.reqHeaders, p.timeout, &q); err != nil {
+		return 0, errors.WithMessagef(err, "failed retrieving queue from %s", p.kind)
+	}
+
+	queueSize := 0
+	switch queue := q.(type) {
+	case []interface{}:
+		queueSize = len(queue)
+	case map[string]interface{}:
+		queueSize = arrRecord(queue).int("totalRecords")
+	}
+
+	p.log.WithField("queue_size", queueSize).Debug("Queue retrieved")
+	return queueSize, nil
+}
+
+func (p *Arr) GetWantedMissing() ([]MediaItem, error) {
+	p.log.Info("Retrieving wanted missing media...")
+	return p.getWanted("missing", p.backend.Missing)
+}
+
+func (p *Arr) GetWantedCutoff() ([]MediaItem, error) {
+	p.log.Info("Retrieving wanted cutoff unmet media...")
+	return p.getWanted("cutoff", p.backend.Cutoff)
+}
+
+func (p *Arr) SearchMediaItems(mediaItemIds []int) (int, error) {
+	return p.sendCommand(map[string]interface{}{
+		"name":                 p.backend.SearchCommand,
+		p.backend.SearchIdsKey: mediaItemIds,
+	})
+}
+
+func (p *Arr) GroupMediaItems(mediaItems []MediaItem) ([]SearchGroup, []MediaItem, error) {
+	switch {
+	case p.backend.Grouping.Parent == "":
+		return nil, mediaItems, nil
+	case p.backend.Grouping.SeasonCommand != "":
+		return p.groupSeasons(mediaItems)
+	}
+
+	// wanted items a parent must exceed to search the whole parent
+	maxItems := p.cfg.Grouping.MaxItems
+	if maxItems <= 0 {
+		maxItems = pvrDefaultGroupMaxItems
+	}
+
+	// group media items by parent
+	var groups []SearchGroup
+	parentIds, parentItems := groupByParent(mediaItems)
+
+	for _, parentId := range parentIds {
+		if len(parentItems[parentId]) <= maxItems {
+			continue
+		}
+
+		groups = append(groups, SearchGroup{
+			Name:     p.backend.Grouping.Parent,
+			ParentId: parentId,
+			Items:    parentItems[parentId],
+		})
+	}
+
+	return groups, ungroupedMediaItems(mediaItems, groups), nil
+}
+
+func (p *Arr) SearchMediaGroup(group SearchGroup) (int, error) {
+	grouping := p.backend.Grouping
+
+	switch {
+	case group.Name == "season" && grouping.SeasonCommand != "":
+		return p.sendCommand(map[string]interface{}{
+			"name":               grouping.SeasonCommand,
+			grouping.ParentIdKey: group.ParentId,
+			"seasonNumber":       group.SeasonNumber,
+		})
+	case group.Name == grouping.Parent && grouping.Parent != "":
+		return p.sendCommand(map[string]interface{}{
+			"name":               grouping.ParentCommand,
+			grouping.ParentIdKey: group.ParentId,
+		})
+	default:
+		return 0, fmt.Errorf("unsupported search group for %s: %q", p.kind, group.Name)
+	}
+}
+
+func (p *Arr) GetCommandStatus(id int) (*CommandStatus, error) {
+	// send request
+	resp, err := web.GetResponse(web.GET, web.JoinURL(p.apiUrl, fmt.Sprintf("/command/%d", id)), p.timeout,
+		p.reqHeaders, &pvrDefaultRetry)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed retrieving command status api response from %s", p.kind)
+	}
+	defer resp.Response().Body.Close()
+
+	// validate response
+	if resp.Response().StatusCode != 200 {
+		return nil, fmt.Errorf("failed retrieving valid command status api response from %s: %s", p.kind,
+			resp.Response().Status)
+	}
+
+	// decode response
+	var s CommandStatus
+	if err := resp.ToJSON(&s); err != nil {
+		return nil, errors.WithMessagef(err, "failed decoding command status api response from %s", p.kind)
+	}
+
+	return &s, nil
+}
+
+func (p *Arr) GetGrabbedMediaItems(since time.Time) (map[int]bool, error) {
+	grabbedIds, err := getGrabbedItemIds(p.apiUrl, p.reqHeaders, p.timeout, since, p.backend.HistoryIdField)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed retrieving grabbed history from %s", p.kind)
+	}
+
+	return grabbedIds, nil
+}
+
+func (p *Arr) GetWantedChanges(since time.Time) (*WantedChanges, error) {
+	changes := p.backend.Changes
+	if changes.ItemEndpoint == "" || p.backend.HistoryIdField == "" || (changes.Missing == nil && changes.Cutoff == nil) {
+		return nil, ErrChangesUnsupported
+	}
+
+	// retrieve items imported or deleted since the last sync
+	changedIds, err := getChangedItemIds(p.apiUrl, p.reqHeaders, p.timeout, since, p.backend.HistoryIdField)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed retrieving changed history from %s", p.kind)
+	}
+
+	// determine whether each changed item is still wanted
+	wantedChanges := &WantedChanges{ChangedIds: changedIds}
+	if changes.Missing == nil {
+		wantedChanges.Unsupported = append(wantedChanges.Unsupported, "missing")
+	}
+	if changes.Cutoff == nil {
+		wantedChanges.Unsupported = append(wantedChanges.Unsupported, "cutoff")
+	}
+
+	if len(changedIds) > pvrDefaultMaxChanges {
+		// retrieving every changed item would be slower than a full refresh
+		p.log.WithField("changed_items", len(changedIds)).Info("Too many changed media items, full refresh required")
+		wantedChanges.Unsupported = []string{"missing", "cutoff"}
+		return wantedChanges, nil
+	}
+
+	// retrieve changed items
+	records := make([]arrRecord, len(changedIds))
+	recordErrs := make([]error, len(changedIds))
+
+	fetchParallel(len(changedIds), func(pos int) {
+		recordErrs[pos] = getApiList(p.apiUrl, fmt.Sprintf("%s/%d", changes.ItemEndpoint, changedIds[pos]),
+			p.reqHeaders, p.timeout, &records[pos])
+	})
+
+	for pos, record := range records {
+		if err := recordErrs[pos]; err != nil && errors.Cause(err) == errApiNotFound {
+			// item was removed
+			continue
+		} else if err != nil {
+			return nil, errors.WithMessagef(err, "failed retrieving changed item %d from %s", changedIds[pos],
+				p.kind)
+		}
+
+		if changes.Missing != nil && record.matches(changes.Missing) {
+			wantedChanges.Missing = append(wantedChanges.Missing, p.mediaItem(record))
+		}
+		if changes.Cutoff != nil && record.matches(changes.Cutoff) {
+			wantedChanges.Cutoff = append(wantedChanges.Cutoff, p.mediaItem(record))
+		}
+	}
+
+	p.log.WithFields(logrus.Fields{
+		"changed_items": len(changedIds),
+		"missing_items": len(wantedChanges.Missing),
+		"cutoff_items":  len(wantedChanges.Cutoff),
+	}).Debug("Retrieved changed media items")
+
+	// apply filters
+	lookups, err := p.getLookups()
+	if err != nil {
+		return nil, err
+	}
+
+	if wantedChanges.Missing, err = filterMediaItems(p.log, p.cfg, "missing", lookups,
+		wantedChanges.Missing); err != nil {
+		return nil, err
+	}
+	if wantedChanges.Cutoff, err = filterMediaItems(p.log, p.cfg, "cutoff", lookups,
+		wantedChanges.Cutoff); err != nil {
+		return nil, err
+	}
+
+	return wantedChanges, nil
+}
+
+/* Private Helpers */
+
+// fetchParallel calls fetch for every position, with at most pvrDefaultPageConcurrency calls running at once
+func fetchParallel(count int, fetch func(pos int)) {
+	slots := make(chan struct{}, pvrDefaultPageConcurrency)
+	var wg sync.WaitGroup
+
+	for pos := 0; pos < count; pos++ {
+		wg.Add(1)
+		slots <- struct{}{}
+
+		go func(pos int) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+
+			fetch(pos)
+		}(pos)
+	}
+
+	wg.Wait()
+}
+
+// getLookups returns the tag labels and quality profile names, retrieved once per run, only failing when they are
+// required by filters and were never retrieved
+func (p *Arr) getLookups() (*pvrLookups, error) {
+	p.lookupsMx.Lock()
+	defer p.lookupsMx.Unlock()
+
+	if p.lookups != nil && !p.lookupsStale {
+		return p.lookups, nil
+	}
+
+	lookups, err := getLookups(p.apiUrl, p.reqHeaders, p.timeout, p.backend.QualityProfilePath)
+	switch {
+	case err == nil:
+		p.lookups, p.lookupsStale = lookups, false
+		return lookups, nil
+	case p.lookups != nil:
+		p.log.WithError(err).Warn("Failed retrieving tag labels and quality profile names, using previous...")
+		return p.lookups, nil
+	case filtersRequireLookups(p.cfg):
+		return nil, err
+	}
+
+	p.log.WithError(err).Warn("Failed retrieving tag labels and quality profile names, they will not be cached...")
+	return &pvrLookups{}, nil
+}
+
+// pageCount returns how many pages hold the total records, sized by the records of the first page (the pvr may
+// return smaller pages than requested)
+func pageCount(firstPageRecords int, totalRecords int) int {
+	if firstPageRecords == 0 || firstPageRecords >= totalRecords {
+		return 1
+	}
+
+	return (totalRecords + firstPageRecords - 1) / firstPageRecords
+}
+
+// groupSeasons groups the media items by season, or by series when every season of the series is wanted
+func (p *Arr) groupSeasons(mediaItems []MediaItem) ([]SearchGroup, []MediaItem, error) {
+	// share of a season that must be wanted to search the whole season
+	minShare := p.cfg.Grouping.MinShare
+	if minShare <= 0 || minShare > 1 {
+		minShare = 1
+	}
+
+	// group media items by series
+	var groups []SearchGroup
+	seriesIds, seriesItems := groupByParent(mediaItems)
+	if len(seriesIds) == 0 {
+		return nil, mediaItems, nil
+	}
+
+	// retrieve seasons of every series (grouping is skipped rather than failing the run)
+	seriesList, err := p.getParents()
+	if err != nil {
+		p.log.WithError(err).Warn("Failed retrieving series, searching items individually...")
+		return nil, mediaItems, nil
+	}
+
+	for _, seriesId := range seriesIds {
+		series, ok := seriesList[seriesId]
+		if !ok {
+			// series removed since the items were cached, search its items individually
+			p.log.WithField("series_id", seriesId).Warn("Series not found, searching its items individually...")
+			continue
+		}
+
+		// group series media items by season
+		seasonItems := make(map[int][]MediaItem)
+		for _, item := range seriesItems[seriesId] {
+			seasonItems[item.SeasonNumber] = append(seasonItems[item.SeasonNumber], item)
+		}
+
+		// determine which seasons are wanted enough to search as a whole
+		var seasonGroups []SearchGroup
+		allSeasonsWanted := true
+
+		for _, season := range series.Seasons {
+			if season.Statistics.TotalEpisodeCount < 1 {
+				continue
+			}
+
+			items := seasonItems[season.SeasonNumber]
+			share := float64(len(items)) / float64(season.Statistics.TotalEpisodeCount)
+
+			if share < minShare {
+				// specials do not prevent a series search
+				if season.SeasonNumber > 0 {
+					allSeasonsWanted = false
+				}
+				continue
+			}
+
+			seasonGroups = append(seasonGroups, SearchGroup{
+				Name:         "season",
+				ParentId:     seriesId,
+				SeasonNumber: season.SeasonNumber,
+				Items:        items,
+			})
+		}
+
+		// search whole series when every season is wanted
+		if allSeasonsWanted && len(seasonGroups) > 1 {
+			groups = append(groups, SearchGroup{
+				Name:     p.backend.Grouping.Parent,
+				ParentId: seriesId,
+				Items:    seriesItems[seriesId],
+			})
+			continue
+		}
+
+		groups = append(groups, seasonGroups...)
+	}
+
+	return groups, ungroupedMediaItems(mediaItems, groups), nil
+}
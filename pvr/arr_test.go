@@ -0,0 +1,116 @@
+package pvr
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/imroc/req"
+	"github.com/migz93/wantarr/logger"
+)
+
+/* Test Page Count */
+
+func TestPageCount(t *testing.T) {
+	tests := []struct {
+		firstPageRecords int
+		totalRecords     int
+		pages            int
+	}{
+		{0, 0, 1},
+		{0, 10, 1},
+		{10, 10, 1},
+		{10, 5, 1},
+		{10, 11, 2},
+		{10, 20, 2},
+		{10, 21, 3},
+		{250, 1000, 4},
+		{250, 1001, 5},
+		{1000, 2500, 3},
+	}
+
+	for _, tc := range tests {
+		if got := pageCount(tc.firstPageRecords, tc.totalRecords); got != tc.pages {
+			t.Errorf("Expected %d pages for %d records of %d per page but got %d", tc.pages, tc.totalRecords,
+				tc.firstPageRecords, got)
+		}
+	}
+}
+
+/* Test Wanted Pages */
+
+// pagedServer serves the ids 1 to totalRecords in pages of at most pvrPageSize records, responding out of order
+func pagedServer(t *testing.T, totalRecords int, pvrPageSize int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		pageSize, _ := strconv.Atoi(r.URL.Query().Get("pageSize"))
+		if pageSize > pvrPageSize {
+			pageSize = pvrPageSize
+		}
+
+		// finish later pages first
+		time.Sleep(time.Duration(rand.Intn(10)) * time.Millisecond)
+
+		var records []string
+		for id := (page-1)*pageSize + 1; id <= page*pageSize && id <= totalRecords; id++ {
+			records = append(records, fmt.Sprintf(`{"id": %d}`, id))
+		}
+
+		_, err := fmt.Fprintf(w, `{"page": %d, "pageSize": %d, "totalRecords": %d, "records": [%s]}`, page,
+			pageSize, totalRecords, strings.Join(records, ","))
+		if err != nil {
+			t.Errorf("Failed writing page %d: %v", page, err)
+		}
+	}))
+}
+
+func TestGetWantedPages(t *testing.T) {
+	tests := []struct {
+		totalRecords int
+		pvrPageSize  int
+	}{
+		{0, 1000},
+		{5, 1000},
+		{1000, 1000},
+		{2500, 1000},
+		{1000, 250},
+		{1001, 250},
+		{37, 3},
+	}
+
+	for _, tc := range tests {
+		srv := pagedServer(t, tc.totalRecords, tc.pvrPageSize)
+
+		p := &Arr{
+			backend: withBackendDefaults(*sonarrBackend("3")),
+			log:     logger.GetLogger("test"),
+			apiUrl:  srv.URL,
+			timeout: 10,
+		}
+
+		mediaItems, records, err := p.getWantedPages(p.backend.Missing, req.QueryParam{"pageSize": 1000})
+		srv.Close()
+		if err != nil {
+			t.Fatalf("Failed retrieving %d records in pages of %d: %v", tc.totalRecords, tc.pvrPageSize, err)
+		}
+
+		if records != tc.totalRecords || len(mediaItems) != tc.totalRecords {
+			t.Errorf("Expected %d records in pages of %d but got %d (%d media items)", tc.totalRecords,
+				tc.pvrPageSize, records, len(mediaItems))
+			continue
+		}
+
+		for pos, item := range mediaItems {
+			if item.ItemId != pos+1 {
+				t.Errorf("Expected id %d at position %d in pages of %d but got %d", pos+1, pos, tc.pvrPageSize,
+					item.ItemId)
+				break
+			}
+		}
+	}
+}
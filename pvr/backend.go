@@ -0,0 +1,324 @@
+package pvr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/migz93/wantarr/config"
+)
+
+var (
+	// backend descriptors per pvr type, extended by those registered from config
+	pvrBackends = map[string]*config.Backend{
+		"sonarr_v3":   sonarrBackend("3"),
+		"sonarr_v4":   sonarrBackend("4"),
+		"radarr_v2":   radarrPagedBackend("0.2", "/profile", "profileId", ""),
+		"radarr_v3":   radarrPagedBackend("3", "/qualityprofile", "qualityProfileId", "collection.name"),
+		"radarr_v4":   radarrBackend("4"),
+		"radarr_v5":   radarrBackend("5"),
+		"lidarr_v2":   lidarrBackend("2"),
+		"readarr_v0":  readarrBackend("0"),
+		"whisparr_v2": whisparrBackend("2"),
+	}
+)
+
+/* Public */
+
+// RegisterBackends adds the backend descriptors set in config, replacing built-in descriptors of the same type
+func RegisterBackends(backends map[string]*config.Backend) error {
+	for pvrType, backend := range backends {
+		if backend == nil {
+			continue
+		}
+
+		// validate backend
+		switch {
+		case backend.ApiPath == "":
+			return fmt.Errorf("no api_path set for backend: %q", pvrType)
+		case len(backend.Versions) == 0:
+			return fmt.Errorf("no versions set for backend: %q", pvrType)
+		case backend.SearchCommand == "" || backend.SearchIdsKey == "":
+			return fmt.Errorf("no search_command / search_ids_key set for backend: %q", pvrType)
+		case backend.Missing.Endpoint == "" && backend.Cutoff.Endpoint == "":
+			return fmt.Errorf("no missing / cutoff endpoint set for backend: %q", pvrType)
+		}
+
+		pvrBackends[strings.ToLower(pvrType)] = backend
+	}
+
+	return nil
+}
+
+/* Private */
+
+func getBackend(pvrType string) (*config.Backend, bool) {
+	backend, ok := pvrBackends[strings.ToLower(pvrType)]
+	return backend, ok
+}
+
+// familyBackends returns the pvr types of the backends belonging to the family, sorted by name
+func familyBackends(pvrFamily string) []string {
+	var pvrTypes []string
+
+	for pvrType, backend := range pvrBackends {
+		if strings.EqualFold(backend.Family, pvrFamily) {
+			pvrTypes = append(pvrTypes, pvrType)
+		}
+	}
+
+	sort.Strings(pvrTypes)
+	return pvrTypes
+}
+
+// versionSupported returns whether the version starts with one of the supported versions
+func versionSupported(version string, supportedVersions []string) bool {
+	for _, supported := range supportedVersions {
+		if version == supported || strings.HasPrefix(version, supported+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+/* Built-in Backends */
+
+func sonarrBackend(version string) *config.Backend {
+	params := map[string]string{
+		"sortKey":       "airDateUtc",
+		"monitored":     "true",
+		"includeSeries": "true",
+	}
+
+	return &config.Backend{
+		Family:   "sonarr",
+		ApiPath:  "/api/v3",
+		Versions: []string{version},
+		Missing:  config.BackendWanted{Endpoint: "/wanted/missing", Paged: true, Params: params},
+		Cutoff:   config.BackendWanted{Endpoint: "/wanted/cutoff", Paged: true, Params: params},
+		Fields: config.BackendFields{
+			ParentId:         "seriesId",
+			ParentTitle:      "series.title",
+			SeasonNumber:     "seasonNumber",
+			QualityProfileId: "series.qualityProfileId",
+			Tags:             "series.tags",
+			SeriesType:       "series.seriesType",
+		},
+		DateFields:     []string{"airDateUtc"},
+		SearchCommand:  "EpisodeSearch",
+		SearchIdsKey:   "episodeIds",
+		HistoryIdField: "episodeId",
+		Grouping: config.BackendGrouping{
+			Parent:         "series",
+			ParentCommand:  "SeriesSearch",
+			ParentIdKey:    "seriesId",
+			ParentEndpoint: "/series",
+			SeasonCommand:  "SeasonSearch",
+		},
+		Changes: episodeChanges(),
+	}
+}
+
+func radarrPagedBackend(version string, qualityProfilePath string, qualityProfileField string,
+	collectionTitleField string) *config.Backend {
+	params := map[string]string{
+		"monitored": "true",
+	}
+
+	fields := config.BackendFields{
+		QualityProfileId: qualityProfileField,
+		Tags:             "tags",
+	}
+	if collectionTitleField != "" {
+		fields.ParentId = "collection.tmdbId"
+		fields.ParentTitle = collectionTitleField
+	}
+
+	return &config.Backend{
+		Family:   "radarr",
+		ApiPath:  "/api",
+		Versions: []string{version},
+		Missing: config.BackendWanted{
+			Endpoint: "/wanted/missing",
+			Paged:    true,
+			Params:   params,
+			Match:    map[string]interface{}{"status": "released"},
+		},
+		Cutoff:             config.BackendWanted{Endpoint: "/wanted/cutoff", Paged: true, Params: params},
+		Fields:             fields,
+		DateFields:         []string{"inCinemas"},
+		SearchCommand:      "moviesSearch",
+		SearchIdsKey:       "movieIds",
+		HistoryIdField:     "movieId",
+		QualityProfilePath: qualityProfilePath,
+		// no changes, the legacy api has no /history/since (or qualityCutoffNotMet on movie files)
+	}
+}
+
+func radarrBackend(version string) *config.Backend {
+	params := map[string]string{
+		"monitored": "true",
+	}
+
+	return &config.Backend{
+		Family:   "radarr",
+		ApiPath:  "/api/v3",
+		Versions: []string{version},
+		Missing: config.BackendWanted{
+			Endpoint: "/wanted/missing",
+			Paged:    true,
+			Params:   params,
+			Match:    map[string]interface{}{"status": "released"},
+			Fallback: &config.BackendWanted{
+				Endpoint: "/movie",
+				Match:    map[string]interface{}{"monitored": true, "status": "released", "hasFile": false},
+			},
+		},
+		Cutoff: config.BackendWanted{
+			Endpoint: "/wanted/cutoff",
+			Paged:    true,
+			Params:   params,
+			Fallback: &config.BackendWanted{
+				Endpoint: "/movie",
+				Match:    map[string]interface{}{"movieFile.qualityCutoffNotMet": true},
+			},
+		},
+		Fields: config.BackendFields{
+			ParentId:         "collection.tmdbId",
+			ParentTitle:      "collection.title",
+			QualityProfileId: "qualityProfileId",
+			Tags:             "tags",
+		},
+		DateFields:     []string{"inCinemas", "digitalRelease", "physicalRelease"},
+		SearchCommand:  "moviesSearch",
+		SearchIdsKey:   "movieIds",
+		HistoryIdField: "movieId",
+		Changes: config.BackendChanges{
+			ItemEndpoint: "/movie",
+			Missing:      map[string]interface{}{"monitored": true, "status": "released", "hasFile": false},
+			Cutoff:       map[string]interface{}{"monitored": true, "movieFile.qualityCutoffNotMet": true},
+		},
+	}
+}
+
+func lidarrBackend(version string) *config.Backend {
+	params := map[string]string{
+		"sortKey":       "airDateUtc",
+		"monitored":     "true",
+		"includeArtist": "true",
+	}
+
+	return &config.Backend{
+		Family:   "lidarr",
+		ApiPath:  "/api/v1",
+		Versions: []string{version},
+		Missing:  config.BackendWanted{Endpoint: "/wanted/missing", Paged: true, Params: params},
+		Cutoff:   config.BackendWanted{Endpoint: "/wanted/cutoff", Paged: true, Params: params},
+		Fields: config.BackendFields{
+			ParentId:         "artistId",
+			ParentTitle:      "artist.artistName",
+			QualityProfileId: "artist.qualityProfileId",
+			Tags:             "artist.tags",
+		},
+		DateFields:     []string{"releaseDate"},
+		SearchCommand:  "AlbumSearch",
+		SearchIdsKey:   "albumIds",
+		HistoryIdField: "albumId",
+		Grouping: config.BackendGrouping{
+			Parent:        "artist",
+			ParentCommand: "ArtistSearch",
+			ParentIdKey:   "artistId",
+		},
+		// albums do not expose whether their cutoff is met, so cutoff is always fully refreshed
+		Changes: config.BackendChanges{
+			ItemEndpoint: "/album",
+			Missing: map[string]interface{}{
+				"monitored":                 true,
+				"artist.monitored":          true,
+				"statistics.trackFileCount": 0,
+			},
+		},
+	}
+}
+
+func readarrBackend(version string) *config.Backend {
+	params := map[string]string{
+		"sortKey":       "airDateUtc",
+		"monitored":     "true",
+		"includeAuthor": "true",
+	}
+
+	return &config.Backend{
+		Family:   "readarr",
+		ApiPath:  "/api/v1",
+		Versions: []string{version},
+		Missing:  config.BackendWanted{Endpoint: "/wanted/missing", Paged: true, Params: params},
+		Cutoff:   config.BackendWanted{Endpoint: "/wanted/cutoff", Paged: true, Params: params},
+		Fields: config.BackendFields{
+			ParentId:         "authorId",
+			ParentTitle:      "author.authorName",
+			QualityProfileId: "author.qualityProfileId",
+			Tags:             "author.tags",
+		},
+		DateFields:     []string{"releaseDate"},
+		SearchCommand:  "BookSearch",
+		SearchIdsKey:   "BookIds",
+		HistoryIdField: "bookId",
+		Grouping: config.BackendGrouping{
+			Parent:        "author",
+			ParentCommand: "AuthorSearch",
+			ParentIdKey:   "authorId",
+		},
+		// books do not expose whether their cutoff is met, so cutoff is always fully refreshed
+		Changes: config.BackendChanges{
+			ItemEndpoint: "/book",
+			Missing: map[string]interface{}{
+				"monitored":                true,
+				"author.monitored":         true,
+				"statistics.bookFileCount": 0,
+			},
+		},
+	}
+}
+
+func whisparrBackend(version string) *config.Backend {
+	params := map[string]string{
+		"sortKey":       "airDateUtc",
+		"monitored":     "true",
+		"includeSeries": "true",
+	}
+
+	return &config.Backend{
+		Family:   "whisparr",
+		ApiPath:  "/api/v3",
+		Versions: []string{version},
+		Missing:  config.BackendWanted{Endpoint: "/wanted/missing", Paged: true, Params: params},
+		Cutoff:   config.BackendWanted{Endpoint: "/wanted/cutoff", Paged: true, Params: params},
+		Fields: config.BackendFields{
+			ParentId:         "seriesId",
+			ParentTitle:      "series.title",
+			SeasonNumber:     "seasonNumber",
+			QualityProfileId: "series.qualityProfileId",
+			Tags:             "series.tags",
+		},
+		DateFields:     []string{"releaseDate"},
+		SearchCommand:  "EpisodeSearch",
+		SearchIdsKey:   "episodeIds",
+		HistoryIdField: "episodeId",
+		Changes:        episodeChanges(),
+	}
+}
+
+// episodeChanges refreshes changed episodes of sonarr and its forks
+func episodeChanges() config.BackendChanges {
+	return config.BackendChanges{
+		ItemEndpoint: "/episode",
+		Missing:      map[string]interface{}{"monitored": true, "series.monitored": true, "hasFile": false},
+		Cutoff: map[string]interface{}{
+			"monitored":                       true,
+			"series.monitored":                true,
+			"episodeFile.qualityCutoffNotMet": true,
+		},
+	}
+}
@@ -0,0 +1,231 @@
+package pvr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/imroc/req"
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/logger"
+	"github.com/migz93/wantarr/utils/web"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	versionCache VersionCache
+)
+
+/* Structs */
+
+// VersionCache stores the pvr type resolved for a family type between runs
+type VersionCache interface {
+	GetPvrType(pvrName string, pvrFamily string) (string, error)
+	SetPvrType(pvrName string, pvrFamily string, pvrType string, version string) error
+}
+
+type pvrSystemStatus struct {
+	Version string
+}
+
+// detectedPvr is returned for family types, detecting the version on init (again when the resolved type fails to init)
+type detectedPvr struct {
+	Interface
+
+	name   string
+	family string
+	cfg    *config.Pvr
+	log    *logrus.Entry
+}
+
+/* Public */
+
+func SetVersionCache(cache VersionCache) {
+	versionCache = cache
+}
+
+/* Private */
+
+func isPvrFamily(pvrType string) bool {
+	if _, ok := getBackend(pvrType); ok {
+		return false
+	}
+
+	return len(familyBackends(pvrType)) > 0
+}
+
+// familyApiPaths returns the api base paths used by the family, most specific first
+func familyApiPaths(pvrTypes []string) []string {
+	var apiPaths []string
+
+	for _, pvrType := range pvrTypes {
+		backend, _ := getBackend(pvrType)
+		if !stringInSlice(backend.ApiPath, apiPaths) {
+			apiPaths = append(apiPaths, backend.ApiPath)
+		}
+	}
+
+	sort.SliceStable(apiPaths, func(i, j int) bool {
+		return len(apiPaths[i]) > len(apiPaths[j])
+	})
+
+	return apiPaths
+}
+
+// familyPvrType returns the pvr type of the family supporting the version
+func familyPvrType(pvrTypes []string, version string) (string, bool) {
+	for _, pvrType := range pvrTypes {
+		if backend, _ := getBackend(pvrType); versionSupported(version, backend.Versions) {
+			return pvrType, true
+		}
+	}
+
+	return "", false
+}
+
+func getDetectedPvr(pvrName string, pvrFamily string, pvrConfig *config.Pvr) (Interface, error) {
+	p := &detectedPvr{
+		name:   pvrName,
+		family: strings.ToLower(pvrFamily),
+		cfg:    pvrConfig,
+		log:    logger.GetLogger(pvrName),
+	}
+
+	// use the cached pvr type when known, otherwise the version is detected on init
+	if versionCache == nil {
+		return p, nil
+	}
+
+	pvrType, err := versionCache.GetPvrType(strings.ToLower(pvrName), p.family)
+	switch {
+	case err != nil:
+		p.log.WithError(err).Warn("Failed retrieving cached pvr version, detecting on init...")
+	case pvrType != "":
+		if p.Interface, err = Get(pvrName, pvrType, pvrConfig); err != nil {
+			// the cached type may no longer exist (e.g. a removed custom backend)
+			p.log.WithError(err).WithField("pvr_type", pvrType).
+				Warn("Failed loading cached pvr version, detecting on init...")
+			p.Interface = nil
+			break
+		}
+
+		p.log.WithField("pvr_type", pvrType).Debug("Using cached pvr version")
+	}
+
+	return p, nil
+}
+
+func getSystemVersion(apiUrl string, apiKey string) (string, error) {
+	// send request
+	resp, err := web.GetResponse(web.GET, web.JoinURL(apiUrl, "/system/status"), pvrDefaultTimeout,
+		req.Header{"X-Api-Key": apiKey}, &pvrDefaultRetry)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed retrieving system status api response")
+	}
+	defer resp.Response().Body.Close()
+
+	// validate response
+	if resp.Response().StatusCode != 200 {
+		return "", fmt.Errorf("failed retrieving valid system status api response: %s", resp.Response().Status)
+	}
+
+	// decode response
+	var s pvrSystemStatus
+	if err := resp.ToJSON(&s); err != nil {
+		return "", errors.WithMessage(err, "failed decoding system status api response")
+	} else if s.Version == "" {
+		return "", errors.New("no version found in system status api response")
+	}
+
+	return s.Version, nil
+}
+
+func (p *detectedPvr) detect() error {
+	familyTypes := familyBackends(p.family)
+
+	// probe the configured api url, or each known api base path
+	apiUrls := []string{p.cfg.URL}
+	if !strings.Contains(p.cfg.URL, "/api") {
+		apiUrls = apiUrls[:0]
+		for _, apiPath := range familyApiPaths(familyTypes) {
+			apiUrls = append(apiUrls, web.JoinURL(p.cfg.URL, apiPath))
+		}
+	}
+
+	var lastErr error
+	for _, apiUrl := range apiUrls {
+		version, err := getSystemVersion(apiUrl, p.cfg.ApiKey)
+		if err != nil {
+			p.log.WithError(err).WithField("api_url", apiUrl).Debug("Failed probing pvr version")
+			lastErr = err
+			continue
+		}
+
+		// determine pvr type
+		pvrType, ok := familyPvrType(familyTypes, version)
+		if !ok {
+			return fmt.Errorf("unsupported version of %s pvr: %s", p.family, version)
+		}
+
+		p.log.WithFields(logrus.Fields{
+			"pvr_type": pvrType,
+			"version":  version,
+		}).Info("Detected pvr version")
+
+		// cache pvr type
+		if versionCache != nil {
+			if err := versionCache.SetPvrType(strings.ToLower(p.name), p.family, pvrType, version); err != nil {
+				p.log.WithError(err).Warn("Failed caching pvr version...")
+			}
+		}
+
+		p.Interface, err = Get(p.name, pvrType, p.cfg)
+		return err
+	}
+
+	return errors.WithMessagef(lastErr, "failed detecting version of %s pvr", p.family)
+}
+
+/* Interface Implements */
+
+func (p *detectedPvr) Init() error {
+	// detect the pvr version when it was not cached
+	if p.Interface == nil {
+		if err := p.detect(); err != nil {
+			return err
+		}
+
+		return p.Interface.Init()
+	}
+
+	err := p.Interface.Init()
+	if err == nil {
+		return nil
+	}
+
+	// the pvr may have been upgraded since the version was cached
+	p.log.WithError(err).Warn("Failed initializing cached pvr version, detecting...")
+
+	if err := p.detect(); err != nil {
+		return err
+	}
+
+	return p.Interface.Init()
+}
+
+func (p *detectedPvr) GroupMediaItems(mediaItems []MediaItem) ([]SearchGroup, []MediaItem, error) {
+	if gs, ok := p.Interface.(GroupSearcher); ok {
+		return gs.GroupMediaItems(mediaItems)
+	}
+
+	return nil, mediaItems, nil
+}
+
+func (p *detectedPvr) SearchMediaGroup(group SearchGroup) (int, error) {
+	if gs, ok := p.Interface.(GroupSearcher); ok {
+		return gs.SearchMediaGroup(group)
+	}
+
+	return 0, fmt.Errorf("unsupported search group for %s: %q", p.family, group.Name)
+}
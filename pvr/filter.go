@@ -0,0 +1,222 @@
+package pvr
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/imroc/req"
+	"github.com/migz93/wantarr/config"
+	"github.com/migz93/wantarr/utils/web"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+/* Structs */
+
+type pvrTag struct {
+	Id    int
+	Label string
+}
+
+type pvrQualityProfile struct {
+	Id   int
+	Name string
+}
+
+type pvrLookups struct {
+	tagLabels    map[int]string
+	profileNames map[int]string
+}
+
+/* Private */
+
+func getApiList(apiUrl string, path string, reqHeaders req.Header, timeout int, list interface{}) error {
+	// send request
+	resp, err := web.GetResponse(web.GET, web.JoinURL(apiUrl, path), timeout, reqHeaders, &pvrDefaultRetry)
+	if err != nil {
+		return errors.WithMessagef(err, "failed retrieving %s api response", path)
+	}
+	defer resp.Response().Body.Close()
+
+	// validate response
+	switch resp.Response().StatusCode {
+	case 200:
+		break
+	case 404:
+		return errors.WithMessagef(errApiNotFound, "failed retrieving valid %s api response", path)
+	default:
+		return fmt.Errorf("failed retrieving valid %s api response: %s", path, resp.Response().Status)
+	}
+
+	// decode response
+	if err := resp.ToJSON(list); err != nil {
+		return errors.WithMessagef(err, "failed decoding %s api response", path)
+	}
+
+	return nil
+}
+
+// resolveIds returns the ids of the names in the id to name lookup, failing when a name is not found (rather than
+// filtering every item out and emptying the cache)
+func resolveIds(kind string, lookup map[int]string, names []string) (map[int]bool, error) {
+	ids := make(map[int]bool)
+
+	for _, name := range names {
+		found := false
+		for id, lookupName := range lookup {
+			if strings.EqualFold(lookupName, strings.TrimSpace(name)) {
+				ids[id] = true
+				found = true
+			}
+		}
+
+		if !found {
+			return nil, fmt.Errorf("no %s found in pvr: %q", kind, name)
+		}
+	}
+
+	return ids, nil
+}
+
+// getLookups retrieves the tag labels and quality profile names of the pvr
+func getLookups(apiUrl string, reqHeaders req.Header, timeout int, qualityProfilePath string) (*pvrLookups, error) {
+	lookups := &pvrLookups{
+		tagLabels:    make(map[int]string),
+		profileNames: make(map[int]string),
+	}
+
+	// retrieve tags
+	var tags []pvrTag
+	if err := getApiList(apiUrl, "/tag", reqHeaders, timeout, &tags); err != nil {
+		return nil, errors.WithMessage(err, "failed retrieving tags")
+	}
+
+	for _, tag := range tags {
+		lookups.tagLabels[tag.Id] = tag.Label
+	}
+
+	// retrieve quality profiles
+	var profiles []pvrQualityProfile
+	if err := getApiList(apiUrl, qualityProfilePath, reqHeaders, timeout, &profiles); err != nil {
+		return nil, errors.WithMessage(err, "failed retrieving quality profiles")
+	}
+
+	for _, profile := range profiles {
+		lookups.profileNames[profile.Id] = profile.Name
+	}
+
+	return lookups, nil
+}
+
+// filtersRequireLookups returns whether tag or quality profile filters are set for the pvr
+func filtersRequireLookups(cfg *config.Pvr) bool {
+	return len(cfg.IncludeTags) > 0 || len(cfg.ExcludeTags) > 0 ||
+		len(cfg.IncludeProfiles.Missing) > 0 || len(cfg.ExcludeProfiles.Missing) > 0 ||
+		len(cfg.IncludeProfiles.Cutoff) > 0 || len(cfg.ExcludeProfiles.Cutoff) > 0
+}
+
+// filterMediaItems sets the tag labels and quality profile name of the media items, and drops those excluded by the
+// tag, quality profile and series type filters set for the pvr
+func filterMediaItems(log *logrus.Entry, cfg *config.Pvr, wantedType string, lookups *pvrLookups,
+	mediaItems []MediaItem) ([]MediaItem, error) {
+	// quality profile filters are set per wanted type
+	includeProfiles, excludeProfiles := cfg.IncludeProfiles.Missing, cfg.ExcludeProfiles.Missing
+	if wantedType == "cutoff" {
+		includeProfiles, excludeProfiles = cfg.IncludeProfiles.Cutoff, cfg.ExcludeProfiles.Cutoff
+	}
+
+	filterTags := len(cfg.IncludeTags) > 0 || len(cfg.ExcludeTags) > 0
+	filterProfiles := len(includeProfiles) > 0 || len(excludeProfiles) > 0
+	filterSeriesTypes := len(cfg.SeriesTypes) > 0
+
+	// label media items
+	for pos := range mediaItems {
+		item := &mediaItems[pos]
+
+		item.QualityProfile = lookups.profileNames[item.QualityProfileId]
+		item.TagLabels = nil
+		for _, tag := range item.Tags {
+			if label, ok := lookups.tagLabels[tag]; ok {
+				item.TagLabels = append(item.TagLabels, label)
+			}
+		}
+	}
+
+	if !filterTags && !filterProfiles && !filterSeriesTypes {
+		return mediaItems, nil
+	}
+
+	// resolve tag labels
+	includeTagIds, err := resolveIds("tag", lookups.tagLabels, cfg.IncludeTags)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed resolving include_tags")
+	}
+	excludeTagIds, err := resolveIds("tag", lookups.tagLabels, cfg.ExcludeTags)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed resolving exclude_tags")
+	}
+
+	// resolve quality profile names
+	includeProfileIds, err := resolveIds("quality profile", lookups.profileNames, includeProfiles)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed resolving include_profiles")
+	}
+	excludeProfileIds, err := resolveIds("quality profile", lookups.profileNames, excludeProfiles)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed resolving exclude_profiles")
+	}
+
+	// filter media items
+	filteredItems := make([]MediaItem, 0, len(mediaItems))
+
+	for _, item := range mediaItems {
+		// tags
+		included := len(cfg.IncludeTags) == 0
+		excluded := false
+
+		for _, tag := range item.Tags {
+			if includeTagIds[tag] {
+				included = true
+			}
+			if excludeTagIds[tag] {
+				excluded = true
+			}
+		}
+
+		if !included || excluded {
+			continue
+		}
+
+		// quality profile
+		if len(includeProfiles) > 0 && !includeProfileIds[item.QualityProfileId] {
+			continue
+		}
+		if excludeProfileIds[item.QualityProfileId] {
+			continue
+		}
+
+		// series type (only for pvrs that expose it)
+		if filterSeriesTypes && item.SeriesType != "" && !stringInSlice(item.SeriesType, cfg.SeriesTypes) {
+			continue
+		}
+
+		filteredItems = append(filteredItems, item)
+	}
+
+	log.WithFields(logrus.Fields{
+		"media_items":   len(filteredItems),
+		"dropped_items": len(mediaItems) - len(filteredItems),
+	}).Info("Filtered media items")
+
+	return filteredItems, nil
+}
+
+func stringInSlice(value string, list []string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
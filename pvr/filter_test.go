@@ -0,0 +1,22 @@
+package pvr
+
+import "testing"
+
+/* Test Name Resolution */
+
+func TestResolveIds(t *testing.T) {
+	lookup := map[int]string{1: "4K", 2: "Remux", 3: "HD-1080p"}
+
+	ids, err := resolveIds("quality profile", lookup, []string{"4k", " Remux "})
+	if err != nil {
+		t.Fatalf("Failed resolving names: %v", err)
+	}
+	if len(ids) != 2 || !ids[1] || !ids[2] {
+		t.Errorf("Expected ids [1 2] but got %v", ids)
+	}
+
+	// unknown names must fail rather than filter every item out
+	if _, err := resolveIds("quality profile", lookup, []string{"4K", "Remx"}); err == nil {
+		t.Error("Expected error for unknown name")
+	}
+}
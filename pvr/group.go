@@ -0,0 +1,42 @@
+package pvr
+
+/* Private */
+
+// groupByParent splits media items by parent, returning the parents in the order they were first seen
+func groupByParent(mediaItems []MediaItem) ([]int, map[int][]MediaItem) {
+	var parentIds []int
+	parentItems := make(map[int][]MediaItem)
+
+	for _, item := range mediaItems {
+		if item.ParentId == 0 {
+			continue
+		}
+
+		if _, ok := parentItems[item.ParentId]; !ok {
+			parentIds = append(parentIds, item.ParentId)
+		}
+
+		parentItems[item.ParentId] = append(parentItems[item.ParentId], item)
+	}
+
+	return parentIds, parentItems
+}
+
+// ungroupedMediaItems returns the media items not covered by any of the groups, in their original order
+func ungroupedMediaItems(mediaItems []MediaItem, groups []SearchGroup) []MediaItem {
+	grouped := make(map[int]bool)
+	for _, group := range groups {
+		for _, item := range group.Items {
+			grouped[item.ItemId] = true
+		}
+	}
+
+	ungrouped := make([]MediaItem, 0, len(mediaItems))
+	for _, item := range mediaItems {
+		if !grouped[item.ItemId] {
+			ungrouped = append(ungrouped, item)
+		}
+	}
+
+	return ungrouped
+}
@@ -0,0 +1,112 @@
+package pvr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/imroc/req"
+	"github.com/migz93/wantarr/utils/web"
+	"github.com/pkg/errors"
+)
+
+var (
+	pvrHistoryPageSize = 100
+)
+
+/* Structs */
+
+type pvrHistory struct {
+	Page         int
+	PageSize     int
+	TotalRecords int
+	Records      []arrRecord
+}
+
+/* Private */
+
+// getGrabbedItemIds returns the ids of the media items grabbed since the given time, newest history first
+func getGrabbedItemIds(apiUrl string, reqHeaders req.Header, timeout int, since time.Time,
+	itemIdField string) (map[int]bool, error) {
+	grabbedIds := make(map[int]bool)
+
+	// set params
+	params := req.QueryParam{
+		"sortKey":       "date",
+		"sortDirection": "descending",
+		"pageSize":      pvrHistoryPageSize,
+	}
+
+	for page := 1; ; page++ {
+		// set page
+		params["page"] = page
+
+		// send request
+		resp, err := web.GetResponse(web.GET, web.JoinURL(apiUrl, "/history"), timeout, reqHeaders,
+			&pvrDefaultRetry, params)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed retrieving history api response")
+		}
+
+		// validate response
+		if resp.Response().StatusCode != 200 {
+			_ = resp.Response().Body.Close()
+			return nil, fmt.Errorf("failed retrieving valid history api response: %s", resp.Response().Status)
+		}
+
+		// decode response
+		var h pvrHistory
+		if err := resp.ToJSON(&h); err != nil {
+			_ = resp.Response().Body.Close()
+			return nil, errors.WithMessage(err, "failed decoding history api response")
+		}
+		_ = resp.Response().Body.Close()
+
+		// process response
+		for _, record := range h.Records {
+			if record.date("date").Before(since) {
+				return grabbedIds, nil
+			}
+
+			if strings.EqualFold(record.string("eventType"), "grabbed") {
+				grabbedIds[record.int(itemIdField)] = true
+			}
+		}
+
+		// break loop when all pages retrieved
+		if len(h.Records) < pvrHistoryPageSize {
+			return grabbedIds, nil
+		}
+	}
+}
+
+// getChangedItemIds returns the ids of the media items imported or deleted since the given time
+func getChangedItemIds(apiUrl string, reqHeaders req.Header, timeout int, since time.Time,
+	itemIdField string) ([]int, error) {
+	var changedIds []int
+	seenIds := make(map[int]bool)
+
+	// set params
+	params := req.QueryParam{
+		"date": since.UTC().Format(time.RFC3339),
+	}
+
+	// retrieve history
+	_, err := getApiRecords(apiUrl, "/history/since", reqHeaders, timeout, params, func(record arrRecord) error {
+		eventType := strings.ToLower(record.string("eventType"))
+		if !strings.Contains(eventType, "imported") && !strings.Contains(eventType, "deleted") {
+			return nil
+		}
+
+		if itemId := record.int(itemIdField); itemId != 0 && !seenIds[itemId] {
+			seenIds[itemId] = true
+			changedIds = append(changedIds, itemId)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changedIds, nil
+}
@@ -2,18 +2,22 @@ package pvr
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/jpillora/backoff"
 	"github.com/migz93/wantarr/config"
 	"github.com/migz93/wantarr/utils/web"
+	"github.com/pkg/errors"
 )
 
 var (
-	pvrDefaultPageSize = 1000
-	pvrDefaultTimeout  = 120
-	pvrDefaultRetry    = web.Retry{
+	ErrChangesUnsupported = errors.New("incremental refresh not supported")
+
+	pvrDefaultPageSize        = 1000
+	pvrDefaultPageConcurrency = 4
+	pvrDefaultTimeout         = 120
+	pvrDefaultGroupMaxItems   = 3
+	pvrDefaultRetry           = web.Retry{
 		MaxAttempts: 6,
 		RetryableStatusCodes: []int{
 			504,
@@ -24,12 +28,48 @@ var (
 			Max:    10 * time.Second,
 		},
 	}
+	// changed items above which a full refresh is quicker than retrieving each changed item
+	pvrDefaultMaxChanges = 500
 )
 
 type MediaItem struct {
-	ItemId     int
-	AirDateUtc time.Time
-	LastSearch time.Time
+	ItemId           int
+	Title            string
+	ParentId         int
+	ParentTitle      string
+	SeasonNumber     int
+	QualityProfileId int
+	QualityProfile   string
+	Tags             []int
+	TagLabels        []string
+	Monitored        bool
+	SeriesType       string
+	AirDateUtc       time.Time
+	LastSearch       time.Time
+}
+
+type SearchGroup struct {
+	Name         string
+	ParentId     int
+	SeasonNumber int
+	Items        []MediaItem
+}
+
+type CommandStatus struct {
+	Name    string
+	Message string
+	Started time.Time
+	Ended   time.Time
+	Status  string
+}
+
+// WantedChanges are the media items changed since a time, changed items not wanted are no longer wanted
+type WantedChanges struct {
+	ChangedIds []int
+	Missing    []MediaItem
+	Cutoff     []MediaItem
+	// wanted types that can not be determined from the changed items, requiring a full refresh
+	Unsupported []string
 }
 
 type Interface interface {
@@ -37,33 +77,32 @@ type Interface interface {
 	GetQueueSize() (int, error)
 	GetWantedMissing() ([]MediaItem, error)
 	GetWantedCutoff() ([]MediaItem, error)
-	SearchMediaItems([]int) (bool, error)
+	// SearchMediaItems submits a search command, returning its id to be tracked with GetCommandStatus
+	SearchMediaItems([]int) (int, error)
+	GetCommandStatus(int) (*CommandStatus, error)
+	// GetGrabbedMediaItems returns the ids of the media items grabbed since the given time
+	GetGrabbedMediaItems(time.Time) (map[int]bool, error)
+	// GetWantedChanges returns the media items imported or deleted since the given time (ErrChangesUnsupported
+	// when the pvr can not report them)
+	GetWantedChanges(time.Time) (*WantedChanges, error)
+}
+
+// GroupSearcher is implemented by pvrs that can search for a whole season/series/artist/author at once
+type GroupSearcher interface {
+	GroupMediaItems([]MediaItem) ([]SearchGroup, []MediaItem, error)
+	SearchMediaGroup(SearchGroup) (int, error)
 }
 
 /* Public */
 
 func Get(pvrName string, pvrType string, pvrConfig *config.Pvr) (Interface, error) {
-	switch strings.ToLower(pvrType) {
-	case "sonarr_v3":
-		return NewSonarrV3(pvrName, pvrConfig), nil
-	case "sonarr_v4":
-		return NewSonarrV4(pvrName, pvrConfig), nil
-	case "radarr_v2":
-		return NewRadarrV2(pvrName, pvrConfig), nil
-	case "radarr_v3":
-		return NewRadarrV3(pvrName, pvrConfig), nil
-	case "radarr_v4":
-		return NewRadarrV4(pvrName, pvrConfig), nil
-	case "radarr_v5":
-		return NewRadarrV5(pvrName, pvrConfig), nil
-	case "lidarr_v2":
-		return NewLidarrV2(pvrName, pvrConfig), nil
-	case "readarr_v0":
-		return NewReadarrV0(pvrName, pvrConfig), nil
-	case "whisparr_v2":
-		return NewWhisparrV2(pvrName, pvrConfig), nil
-	default:
-		break
+	// detect the version of family types (sonarr, radarr etc)
+	if isPvrFamily(pvrType) {
+		return getDetectedPvr(pvrName, pvrType, pvrConfig)
+	}
+
+	if backend, ok := getBackend(pvrType); ok {
+		return NewArr(pvrName, pvrType, backend, pvrConfig), nil
 	}
 
 	return nil, fmt.Errorf("unsupported pvr type provided: %q", pvrType)
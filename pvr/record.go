@@ -0,0 +1,137 @@
+package pvr
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/* Structs */
+
+// arrRecord is a json object returned by an arr api, read through the field paths of a backend
+type arrRecord map[string]interface{}
+
+/* Private */
+
+// value returns the value at the dot separated field path, matching field names case-insensitively
+func (r arrRecord) value(path string) interface{} {
+	if path == "" {
+		return nil
+	}
+
+	var current interface{} = map[string]interface{}(r)
+	for _, name := range strings.Split(path, ".") {
+		fields, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		value, ok := fields[name]
+		if !ok {
+			for field, fieldValue := range fields {
+				if strings.EqualFold(field, name) {
+					value, ok = fieldValue, true
+					break
+				}
+			}
+		}
+
+		if !ok {
+			return nil
+		}
+		current = value
+	}
+
+	return current
+}
+
+func (r arrRecord) int(path string) int {
+	if value, ok := r.value(path).(float64); ok {
+		return int(value)
+	}
+
+	return 0
+}
+
+func (r arrRecord) string(path string) string {
+	if value, ok := r.value(path).(string); ok {
+		return value
+	}
+
+	return ""
+}
+
+func (r arrRecord) bool(path string) bool {
+	if value, ok := r.value(path).(bool); ok {
+		return value
+	}
+
+	return false
+}
+
+func (r arrRecord) ints(path string) []int {
+	values, ok := r.value(path).([]interface{})
+	if !ok {
+		return nil
+	}
+
+	ints := make([]int, 0, len(values))
+	for _, value := range values {
+		if number, ok := value.(float64); ok {
+			ints = append(ints, int(number))
+		}
+	}
+
+	return ints
+}
+
+// date returns the time at the field path, accepting full timestamps and plain dates
+func (r arrRecord) date(path string) time.Time {
+	value := r.string(path)
+	if value == "" {
+		return time.Time{}
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+
+	t, _ := time.Parse("2006-01-02", value)
+	return t
+}
+
+// latestDate returns the latest of the times at the field paths
+func (r arrRecord) latestDate(paths []string) time.Time {
+	var latest time.Time
+
+	for _, path := range paths {
+		if t := r.date(path); t.After(latest) {
+			latest = t
+		}
+	}
+
+	return latest
+}
+
+// matches returns whether every field path holds the wanted value (nested maps are read as nested field paths)
+func (r arrRecord) matches(match map[string]interface{}) bool {
+	for path, wanted := range match {
+		if nested, ok := wanted.(map[string]interface{}); ok {
+			prefixed := make(map[string]interface{}, len(nested))
+			for field, value := range nested {
+				prefixed[path+"."+field] = value
+			}
+
+			if !r.matches(prefixed) {
+				return false
+			}
+			continue
+		}
+
+		if !strings.EqualFold(fmt.Sprint(r.value(path)), fmt.Sprint(wanted)) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,64 @@
+package pvr
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+/* Test Record Fields */
+
+func TestRecordFields(t *testing.T) {
+	var r arrRecord
+	if err := json.Unmarshal([]byte(`{"id": 5, "title": "Movie", "monitored": true, "tags": [1, 3],
+		"collection": {"title": "Collection", "tmdbId": 9}, "inCinemas": "2020-01-01T00:00:00Z",
+		"releaseDate": "2020-03-01", "movieFile": {"qualityCutoffNotMet": true}}`), &r); err != nil {
+		t.Fatalf("Failed decoding record: %v", err)
+	}
+
+	if got := r.int("id"); got != 5 {
+		t.Errorf("Expected id 5 but got %d", got)
+	}
+	if got := r.string("Collection.Title"); got != "Collection" {
+		t.Errorf("Expected collection title %q but got %q", "Collection", got)
+	}
+	if got := r.ints("tags"); len(got) != 2 || got[1] != 3 {
+		t.Errorf("Expected tags [1 3] but got %v", got)
+	}
+	if got := r.int("series.id"); got != 0 {
+		t.Errorf("Expected missing field to be 0 but got %d", got)
+	}
+
+	want := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+	if got := r.latestDate([]string{"inCinemas", "releaseDate", "digitalRelease"}); !got.Equal(want) {
+		t.Errorf("Expected latest date %s but got %s", want, got)
+	}
+}
+
+/* Test Record Match */
+
+func TestRecordMatches(t *testing.T) {
+	var r arrRecord
+	if err := json.Unmarshal([]byte(`{"monitored": true, "status": "released", "hasFile": false,
+		"movieFile": {"qualityCutoffNotMet": true}}`), &r); err != nil {
+		t.Fatalf("Failed decoding record: %v", err)
+	}
+
+	tests := []struct {
+		match map[string]interface{}
+		want  bool
+	}{
+		{map[string]interface{}{"monitored": true, "status": "Released", "hasFile": false}, true},
+		{map[string]interface{}{"hasFile": true}, false},
+		{map[string]interface{}{"movieFile.qualityCutoffNotMet": true}, true},
+		{map[string]interface{}{"moviefile": map[string]interface{}{"qualitycutoffnotmet": true}}, true},
+		{map[string]interface{}{"series.title": "x"}, false},
+		{nil, true},
+	}
+
+	for _, tc := range tests {
+		if got := r.matches(tc.match); got != tc.want {
+			t.Errorf("Expected %v to match %v but got %v", tc.match, tc.want, got)
+		}
+	}
+}
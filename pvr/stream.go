@@ -0,0 +1,121 @@
+package pvr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/imroc/req"
+	"github.com/migz93/wantarr/utils/web"
+	"github.com/pkg/errors"
+)
+
+var (
+	errApiNotFound = errors.New("api endpoint not found")
+)
+
+/* Private */
+
+// getApiRecords streams the records of a list or paged api response, returning the total records of paged responses
+func getApiRecords(apiUrl string, path string, reqHeaders req.Header, timeout int, params req.QueryParam,
+	record func(arrRecord) error) (int, error) {
+	// send request
+	resp, err := web.GetResponse(web.GET, web.JoinURL(apiUrl, path), timeout, reqHeaders, &pvrDefaultRetry, params)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed retrieving %s api response", path)
+	}
+	defer resp.Response().Body.Close()
+
+	// validate response
+	switch resp.Response().StatusCode {
+	case 200:
+		break
+	case 404:
+		return 0, errors.WithMessagef(errApiNotFound, "failed retrieving valid %s api response", path)
+	default:
+		return 0, fmt.Errorf("failed retrieving valid %s api response: %s", path, resp.Response().Status)
+	}
+
+	// decode response
+	totalRecords, err := decodeRecords(resp.Response().Body, record)
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed decoding %s api response", path)
+	}
+
+	return totalRecords, nil
+}
+
+// decodeRecords decodes a json array, or the records array of a paged json object, one record at a time
+func decodeRecords(r io.Reader, record func(arrRecord) error) (int, error) {
+	dec := json.NewDecoder(r)
+
+	token, err := dec.Token()
+	if err != nil {
+		return 0, err
+	}
+
+	switch token {
+	case json.Delim('['):
+		return 0, decodeArrayRecords(dec, record)
+	case json.Delim('{'):
+		break
+	default:
+		return 0, fmt.Errorf("unexpected json token: %v", token)
+	}
+
+	// paged response
+	totalRecords := 0
+
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return 0, err
+		}
+
+		key, _ := token.(string)
+		switch {
+		case strings.EqualFold(key, "totalRecords"):
+			if err := dec.Decode(&totalRecords); err != nil {
+				return 0, err
+			}
+		case strings.EqualFold(key, "records"):
+			token, err := dec.Token()
+			if err != nil {
+				return 0, err
+			} else if token == nil {
+				continue
+			} else if token != json.Delim('[') {
+				return 0, fmt.Errorf("unexpected json token for records: %v", token)
+			}
+
+			if err := decodeArrayRecords(dec, record); err != nil {
+				return 0, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return totalRecords, nil
+}
+
+// decodeArrayRecords decodes the remaining records of an array, consuming its closing delimiter
+func decodeArrayRecords(dec *json.Decoder, record func(arrRecord) error) error {
+	for dec.More() {
+		var r arrRecord
+		if err := dec.Decode(&r); err != nil {
+			return err
+		}
+
+		if err := record(r); err != nil {
+			return err
+		}
+	}
+
+	_, err := dec.Token()
+	return err
+}
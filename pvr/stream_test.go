@@ -0,0 +1,166 @@
+package pvr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+/* Test Record Decoding */
+
+func TestDecodeRecords(t *testing.T) {
+	tests := []struct {
+		body         string
+		ids          []int
+		totalRecords int
+	}{
+		{`[{"id": 1}, {"id": 2}]`, []int{1, 2}, 0},
+		{`[]`, nil, 0},
+		{`{"page": 1, "records": [{"id": 3, "series": {"id": 9}}], "totalRecords": 5}`, []int{3}, 5},
+		{`{"totalRecords": 0, "records": null}`, nil, 0},
+	}
+
+	for _, tc := range tests {
+		var ids []int
+		totalRecords, err := decodeRecords(strings.NewReader(tc.body), func(record arrRecord) error {
+			ids = append(ids, record.int("id"))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Failed decoding %q: %v", tc.body, err)
+		}
+
+		if fmt.Sprint(ids) != fmt.Sprint(tc.ids) || totalRecords != tc.totalRecords {
+			t.Errorf("Expected %v (%d total) from %q but got %v (%d total)", tc.ids, tc.totalRecords, tc.body,
+				ids, totalRecords)
+		}
+	}
+
+	for _, body := range []string{`"records"`, `[{"id": 1}`, `{"records": {}}`} {
+		if _, err := decodeRecords(strings.NewReader(body), func(arrRecord) error { return nil }); err == nil {
+			t.Errorf("Expected decode error for %q", body)
+		}
+	}
+}
+
+/* Benchmark Record Decoding */
+
+const benchmarkRecords = 100000
+
+// benchmarkMovies returns a synthetic /movie response
+func benchmarkMovies() []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[")
+
+	for i := 0; i < benchmarkRecords; i++ {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		_, _ = fmt.Fprintf(&buf, `{"id":%d,"title":"Movie %d","originalTitle":"Movie %d","sortTitle":"movie %d",`+
+			`"overview":"A synthetic movie used to benchmark decoding of large wanted list responses.",`+
+			`"collection":{"title":"Collection %d","tmdbId":%d},"qualityProfileId":1,"tags":[1,2],`+
+			`"inCinemas":"2020-01-01T00:00:00Z","digitalRelease":"2020-03-01T00:00:00Z",`+
+			`"physicalRelease":"2020-04-01T00:00:00Z","status":"released","monitored":true,"hasFile":%t,`+
+			`"movieFile":{"qualityCutoffNotMet":%t},"images":[{"coverType":"poster","url":"/poster.jpg"}]}`,
+			i, i, i, i, i%500, i%500, i%2 == 0, i%3 == 0)
+	}
+
+	buf.WriteString("]")
+	return buf.Bytes()
+}
+
+// peakHeap records the largest heap seen while decoding
+type peakHeap struct {
+	base uint64
+	peak uint64
+}
+
+func newPeakHeap() *peakHeap {
+	runtime.GC()
+
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return &peakHeap{base: m.HeapAlloc}
+}
+
+func (h *peakHeap) sample() {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	if m.HeapAlloc > h.base && m.HeapAlloc-h.base > h.peak {
+		h.peak = m.HeapAlloc - h.base
+	}
+}
+
+func BenchmarkDecodeRecordsBuffered(b *testing.B) {
+	body := benchmarkMovies()
+	p := &Arr{backend: withBackendDefaults(*radarrBackend("5"))}
+	match := p.backend.Missing.Fallback.Match
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		heap := newPeakHeap()
+
+		// read the whole response before decoding
+		buffered, err := io.ReadAll(bytes.NewReader(body))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var records []arrRecord
+		if err := json.Unmarshal(buffered, &records); err != nil {
+			b.Fatal(err)
+		}
+		heap.sample()
+
+		var mediaItems []MediaItem
+		for _, record := range records {
+			if record.matches(match) {
+				mediaItems = append(mediaItems, p.mediaItem(record))
+			}
+		}
+		heap.sample()
+
+		b.ReportMetric(float64(heap.peak)/(1<<20), "peak-MB")
+		runtime.KeepAlive(records)
+	}
+}
+
+func BenchmarkDecodeRecordsStreaming(b *testing.B) {
+	body := benchmarkMovies()
+	p := &Arr{backend: withBackendDefaults(*radarrBackend("5"))}
+	match := p.backend.Missing.Fallback.Match
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		heap := newPeakHeap()
+
+		var mediaItems []MediaItem
+		decoded := 0
+		_, err := decodeRecords(bytes.NewReader(body), func(record arrRecord) error {
+			if record.matches(match) {
+				mediaItems = append(mediaItems, p.mediaItem(record))
+			}
+
+			if decoded++; decoded%10000 == 0 {
+				heap.sample()
+			}
+			return nil
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		heap.sample()
+
+		b.ReportMetric(float64(heap.peak)/(1<<20), "peak-MB")
+	}
+}
@@ -0,0 +1,354 @@
+package expr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+/* Structs */
+
+// Expression is a compiled rule, e.g. `series_type == "anime" and air_age < 2y and search_attempts < 5`
+//
+// Values are numbers, strings, booleans, durations (`90d`, `12h`, `2y`), times and lists (`["a", "b"]`).
+type Expression struct {
+	source string
+	root   node
+}
+
+/* Public */
+
+func Compile(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Identifiers returns the sorted names of the fields used by the expression
+func (e *Expression) Identifiers() []string {
+	names := make(map[string]bool)
+	identifiers(e.root, names)
+
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+func (e *Expression) Eval(env map[string]interface{}) (interface{}, error) {
+	return eval(e.root, env)
+}
+
+func (e *Expression) EvalBool(env map[string]interface{}) (bool, error) {
+	value, err := e.Eval(env)
+	if err != nil {
+		return false, err
+	}
+
+	b, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression did not evaluate to a boolean: %v", value)
+	}
+
+	return b, nil
+}
+
+/* Private */
+
+// normalize converts integer values to float64 so numbers can be compared
+func normalize(value interface{}) interface{} {
+	switch v := value.(type) {
+	case int:
+		return float64(v)
+	case int64:
+		return float64(v)
+	case []int:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			list = append(list, float64(item))
+		}
+		return list
+	case []string:
+		list := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			list = append(list, item)
+		}
+		return list
+	default:
+		return value
+	}
+}
+
+func eval(n node, env map[string]interface{}) (interface{}, error) {
+	switch n := n.(type) {
+	case *literalNode:
+		return n.value, nil
+
+	case *identNode:
+		value, ok := env[n.name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field: %q", n.name)
+		}
+		return normalize(value), nil
+
+	case *listNode:
+		list := make([]interface{}, 0, len(n.items))
+		for _, item := range n.items {
+			value, err := eval(item, env)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+
+	case *unaryNode:
+		x, err := eval(n.x, env)
+		if err != nil {
+			return nil, err
+		}
+		return evalUnary(n.op, x)
+
+	case *binaryNode:
+		return evalBinary(n, env)
+
+	default:
+		return nil, fmt.Errorf("unsupported expression node: %T", n)
+	}
+}
+
+func evalUnary(op string, x interface{}) (interface{}, error) {
+	switch v := x.(type) {
+	case bool:
+		if op == "!" {
+			return !v, nil
+		}
+	case float64:
+		if op == "-" {
+			return -v, nil
+		}
+	case time.Duration:
+		if op == "-" {
+			return -v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported operator %q for %s", op, typeName(x))
+}
+
+func evalBinary(n *binaryNode, env map[string]interface{}) (interface{}, error) {
+	left, err := eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	// short circuit logical operators
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unsupported operator %q for %s", n.op, typeName(left))
+		}
+		if (n.op == "&&" && !l) || (n.op == "||" && l) {
+			return l, nil
+		}
+
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("unsupported operator %q for %s", n.op, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "<", "<=", ">", ">=":
+		return compare(n.op, left, right)
+	case "in":
+		list, ok := right.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unsupported operator %q for %s", n.op, typeName(right))
+		}
+		return listContains(list, left), nil
+	case "contains":
+		switch l := left.(type) {
+		case []interface{}:
+			return listContains(l, right), nil
+		case string:
+			if r, ok := right.(string); ok {
+				return strings.Contains(strings.ToLower(l), strings.ToLower(r)), nil
+			}
+		}
+		return nil, fmt.Errorf("unsupported operator %q for %s and %s", n.op, typeName(left), typeName(right))
+	case "+", "-":
+		return arithmetic(n.op, left, right)
+	default:
+		return nil, fmt.Errorf("unsupported operator: %q", n.op)
+	}
+}
+
+func equal(left interface{}, right interface{}) bool {
+	switch l := left.(type) {
+	case time.Time:
+		r, ok := right.(time.Time)
+		return ok && l.Equal(r)
+	case []interface{}:
+		r, ok := right.([]interface{})
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for pos := range l {
+			if !equal(l[pos], r[pos]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return left == right
+	}
+}
+
+func listContains(list []interface{}, value interface{}) bool {
+	for _, item := range list {
+		if equal(item, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func compare(op string, left interface{}, right interface{}) (bool, error) {
+	var cmp int
+
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s with %s", typeName(left), typeName(right))
+		}
+		cmp = compareOrdered(l < r, l > r)
+	case time.Duration:
+		r, ok := right.(time.Duration)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s with %s", typeName(left), typeName(right))
+		}
+		cmp = compareOrdered(l < r, l > r)
+	case time.Time:
+		r, ok := right.(time.Time)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s with %s", typeName(left), typeName(right))
+		}
+		cmp = compareOrdered(l.Before(r), l.After(r))
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false, fmt.Errorf("cannot compare %s with %s", typeName(left), typeName(right))
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false, fmt.Errorf("cannot compare %s with %s", typeName(left), typeName(right))
+	}
+
+	switch op {
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp >= 0, nil
+	}
+}
+
+func compareOrdered(less bool, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func arithmetic(op string, left interface{}, right interface{}) (interface{}, error) {
+	sign := 1
+	if op == "-" {
+		sign = -1
+	}
+
+	switch l := left.(type) {
+	case float64:
+		if r, ok := right.(float64); ok {
+			return l + float64(sign)*r, nil
+		}
+	case time.Duration:
+		if r, ok := right.(time.Duration); ok {
+			return l + time.Duration(sign)*r, nil
+		}
+	case time.Time:
+		switch r := right.(type) {
+		case time.Duration:
+			return l.Add(time.Duration(sign) * r), nil
+		case time.Time:
+			if op == "-" {
+				return l.Sub(r), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported operator %q for %s and %s", op, typeName(left), typeName(right))
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case time.Duration:
+		return "duration"
+	case time.Time:
+		return "time"
+	case []interface{}:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,94 @@
+package expr
+
+import (
+	"testing"
+	"time"
+)
+
+/* Test Expression Eval */
+
+func TestEvalBool(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	env := map[string]interface{}{
+		"now":             now,
+		"series_type":     "anime",
+		"title":           "Some Episode",
+		"air_date":        now.Add(-400 * 24 * time.Hour),
+		"air_age":         400 * 24 * time.Hour,
+		"search_attempts": 3,
+		"tags":            []int{1, 4},
+		"monitored":       true,
+	}
+
+	tests := []struct {
+		expression string
+		want       bool
+	}{
+		{`series_type == "anime" and air_age < 2y and search_attempts < 5`, true},
+		{`series_type == 'anime' && search_attempts >= 5`, false},
+		{`air_date > now - 365d`, false},
+		{`air_date > now - 2y || monitored == false`, true},
+		{`not monitored`, false},
+		{`!(search_attempts == 3)`, false},
+		{`tags contains 4`, true},
+		{`2 in tags`, false},
+		{`series_type in ["standard", "anime"]`, true},
+		{`title contains "episode"`, true},
+		{`search_attempts + 2 <= 5`, true},
+		{`now - air_date == air_age`, true},
+		{`-1 < 0`, true},
+	}
+
+	for _, tc := range tests {
+		e, err := Compile(tc.expression)
+		if err != nil {
+			t.Fatalf("Failed compiling %q: %v", tc.expression, err)
+		}
+
+		got, err := e.EvalBool(env)
+		if err != nil {
+			t.Fatalf("Failed evaluating %q: %v", tc.expression, err)
+		}
+		if got != tc.want {
+			t.Errorf("Expected %q to be %v but got %v", tc.expression, tc.want, got)
+		}
+	}
+}
+
+/* Test Expression Errors */
+
+func TestCompileInvalid(t *testing.T) {
+	for _, expression := range []string{"", "a ==", "(a == 1", `a == "b`, "a == 1 b", "5x > 1", "a # b"} {
+		if _, err := Compile(expression); err == nil {
+			t.Errorf("Expected compile error for %q", expression)
+		}
+	}
+}
+
+func TestEvalInvalid(t *testing.T) {
+	env := map[string]interface{}{"title": "x", "search_attempts": 1}
+
+	for _, expression := range []string{"unknown == 1", "title < 1", "search_attempts", "title and true"} {
+		e, err := Compile(expression)
+		if err != nil {
+			t.Fatalf("Failed compiling %q: %v", expression, err)
+		}
+
+		if _, err := e.EvalBool(env); err == nil {
+			t.Errorf("Expected eval error for %q", expression)
+		}
+	}
+}
+
+/* Test Expression Identifiers */
+
+func TestIdentifiers(t *testing.T) {
+	e, err := Compile(`title contains "a" and (air_age < 1y or title == "b") and true`)
+	if err != nil {
+		t.Fatalf("Failed compiling expression: %v", err)
+	}
+
+	if got := e.Identifiers(); len(got) != 2 || got[0] != "air_age" || got[1] != "title" {
+		t.Errorf("Expected identifiers [air_age title] but got %v", got)
+	}
+}
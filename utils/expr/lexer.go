@@ -0,0 +1,140 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+/* Structs */
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenDuration
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind  tokenKind
+	text  string
+	value interface{}
+	pos   int
+}
+
+/* Private */
+
+var (
+	// longest operators first
+	operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "+", "-", "(", ")", "[", "]", ","}
+
+	durationUnits = map[string]time.Duration{
+		"s": time.Second,
+		"m": time.Minute,
+		"h": time.Hour,
+		"d": 24 * time.Hour,
+		"w": 7 * 24 * time.Hour,
+		"y": 365 * 24 * time.Hour,
+	}
+)
+
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	runes := []rune(source)
+
+	for pos := 0; pos < len(runes); {
+		r := runes[pos]
+
+		switch {
+		case unicode.IsSpace(r):
+			pos++
+
+		case unicode.IsDigit(r):
+			// number, optionally followed by a duration unit
+			start := pos
+			for pos < len(runes) && (unicode.IsDigit(runes[pos]) || runes[pos] == '.') {
+				pos++
+			}
+
+			number, err := strconv.ParseFloat(string(runes[start:pos]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number at position %d: %q", start, string(runes[start:pos]))
+			}
+
+			unitStart := pos
+			for pos < len(runes) && unicode.IsLetter(runes[pos]) {
+				pos++
+			}
+
+			if unit := string(runes[unitStart:pos]); unit != "" {
+				unitDuration, ok := durationUnits[unit]
+				if !ok {
+					return nil, fmt.Errorf("invalid duration unit at position %d: %q", unitStart, unit)
+				}
+
+				tokens = append(tokens, token{kind: tokenDuration, text: string(runes[start:pos]),
+					value: time.Duration(number * float64(unitDuration)), pos: start})
+				continue
+			}
+
+			tokens = append(tokens, token{kind: tokenNumber, text: string(runes[start:pos]), value: number,
+				pos: start})
+
+		case r == '"' || r == '\'':
+			// quoted string
+			start := pos
+			pos++
+
+			var sb strings.Builder
+			for pos < len(runes) && runes[pos] != r {
+				if runes[pos] == '\\' && pos+1 < len(runes) {
+					pos++
+				}
+				sb.WriteRune(runes[pos])
+				pos++
+			}
+
+			if pos >= len(runes) {
+				return nil, fmt.Errorf("unterminated string at position %d", start)
+			}
+			pos++
+
+			tokens = append(tokens, token{kind: tokenString, text: string(runes[start:pos]), value: sb.String(),
+				pos: start})
+
+		case unicode.IsLetter(r) || r == '_':
+			// identifier or keyword
+			start := pos
+			for pos < len(runes) && (unicode.IsLetter(runes[pos]) || unicode.IsDigit(runes[pos]) || runes[pos] == '_') {
+				pos++
+			}
+
+			tokens = append(tokens, token{kind: tokenIdent, text: string(runes[start:pos]), pos: start})
+
+		default:
+			// operator
+			matched := ""
+			for _, op := range operators {
+				if strings.HasPrefix(string(runes[pos:]), op) {
+					matched = op
+					break
+				}
+			}
+
+			if matched == "" {
+				return nil, fmt.Errorf("unexpected character at position %d: %q", pos, string(r))
+			}
+
+			tokens = append(tokens, token{kind: tokenOperator, text: matched, pos: pos})
+			pos += len([]rune(matched))
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(runes)}), nil
+}
@@ -0,0 +1,270 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+/* Structs */
+
+type node interface{}
+
+type literalNode struct {
+	value interface{}
+}
+
+type identNode struct {
+	name string
+}
+
+type listNode struct {
+	items []node
+}
+
+type unaryNode struct {
+	op string
+	x  node
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+/* Private */
+
+// keywords usable in place of the symbolic operators
+var keywordOperators = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// operator returns the operator of the next token, translating keyword operators
+func (p *parser) operator() string {
+	t := p.peek()
+
+	switch t.kind {
+	case tokenOperator:
+		return t.text
+	case tokenIdent:
+		if op, ok := keywordOperators[strings.ToLower(t.text)]; ok {
+			return op
+		}
+		if lower := strings.ToLower(t.text); lower == "in" || lower == "contains" {
+			return lower
+		}
+	}
+
+	return ""
+}
+
+func (p *parser) expect(op string) error {
+	if p.operator() != op {
+		t := p.peek()
+		return fmt.Errorf("expected %q at position %d but found %q", op, t.pos, t.text)
+	}
+
+	p.next()
+	return nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.operator() == "||" {
+		p.next()
+
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.operator() == "&&" {
+		p.next()
+
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseNot() (node, error) {
+	if p.operator() == "!" {
+		p.next()
+
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "!", x: x}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op := p.operator(); op {
+	case "==", "!=", "<", "<=", ">", ">=", "in", "contains":
+		p.next()
+
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &binaryNode{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for op := p.operator(); op == "+" || op == "-"; op = p.operator() {
+		p.next()
+
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.operator() == "-" {
+		p.next()
+
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: "-", x: x}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	t := p.peek()
+
+	switch {
+	case t.kind == tokenNumber || t.kind == tokenDuration || t.kind == tokenString:
+		p.next()
+		return &literalNode{value: t.value}, nil
+
+	case t.kind == tokenIdent && p.operator() == "":
+		p.next()
+
+		switch strings.ToLower(t.text) {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		return &identNode{name: strings.ToLower(t.text)}, nil
+
+	case p.operator() == "(":
+		p.next()
+
+		x, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return x, nil
+
+	case p.operator() == "[":
+		p.next()
+
+		list := &listNode{}
+		for p.operator() != "]" {
+			item, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			list.items = append(list.items, item)
+
+			if p.operator() != "," {
+				break
+			}
+			p.next()
+		}
+
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+		return list, nil
+
+	case t.kind == tokenEOF:
+		return nil, fmt.Errorf("unexpected end of expression at position %d", t.pos)
+
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", t.text, t.pos)
+	}
+}
+
+// identifiers returns the names of the identifiers used within the node
+func identifiers(n node, names map[string]bool) {
+	switch n := n.(type) {
+	case *identNode:
+		names[n.name] = true
+	case *listNode:
+		for _, item := range n.items {
+			identifiers(item, names)
+		}
+	case *unaryNode:
+		identifiers(n.x, names)
+	case *binaryNode:
+		identifiers(n.left, names)
+		identifiers(n.right, names)
+	}
+}
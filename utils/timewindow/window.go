@@ -0,0 +1,109 @@
+package timewindow
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+/* Structs */
+
+// Window is a daily time range, in minutes since midnight, that may cross midnight
+type Window struct {
+	Start int
+	End   int
+}
+
+/* Public */
+
+func Parse(text string) (Window, error) {
+	parts := strings.Split(strings.TrimSpace(text), "-")
+	if len(parts) != 2 {
+		return Window{}, fmt.Errorf("invalid time window, expected HH:MM-HH:MM: %q", text)
+	}
+
+	start, err := parseClock(parts[0])
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid time window start %q: %v", text, err)
+	}
+
+	end, err := parseClock(parts[1])
+	if err != nil {
+		return Window{}, fmt.Errorf("invalid time window end %q: %v", text, err)
+	}
+
+	return Window{Start: start, End: end}, nil
+}
+
+func ParseList(texts []string) ([]Window, error) {
+	windows := make([]Window, 0, len(texts))
+
+	for _, text := range texts {
+		w, err := Parse(text)
+		if err != nil {
+			return nil, err
+		}
+
+		windows = append(windows, w)
+	}
+
+	return windows, nil
+}
+
+// Contains returns whether t, in its own location, falls within the window
+func (w Window) Contains(t time.Time) bool {
+	minute := t.Hour()*60 + t.Minute()
+
+	switch {
+	case w.Start == w.End:
+		return true
+	case w.Start < w.End:
+		return minute >= w.Start && minute < w.End
+	default:
+		// crosses midnight
+		return minute >= w.Start || minute < w.End
+	}
+}
+
+// AnyContains returns whether t falls within any of the windows
+func AnyContains(windows []Window, t time.Time) bool {
+	for _, w := range windows {
+		if w.Contains(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NextStart returns the next time after t that one of the windows opens, in the location of t
+func NextStart(windows []Window, t time.Time) time.Time {
+	var next time.Time
+
+	for _, w := range windows {
+		for day := 0; day <= 1; day++ {
+			start := time.Date(t.Year(), t.Month(), t.Day()+day, w.Start/60, w.Start%60, 0, 0, t.Location())
+			if !start.After(t) {
+				continue
+			}
+
+			if next.IsZero() || start.Before(next) {
+				next = start
+			}
+			break
+		}
+	}
+
+	return next
+}
+
+/* Private */
+
+func parseClock(text string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(text))
+	if err != nil {
+		return 0, err
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
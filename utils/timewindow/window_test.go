@@ -0,0 +1,66 @@
+package timewindow
+
+import (
+	"testing"
+	"time"
+)
+
+/* Test Window Contains */
+
+func TestWindowContains(t *testing.T) {
+	tests := []struct {
+		window string
+		clock  string
+		want   bool
+	}{
+		{"01:00-07:00", "00:59", false},
+		{"01:00-07:00", "01:00", true},
+		{"01:00-07:00", "06:59", true},
+		{"01:00-07:00", "07:00", false},
+		{"22:00-02:00", "23:30", true},
+		{"22:00-02:00", "01:59", true},
+		{"22:00-02:00", "12:00", false},
+		{"00:00-00:00", "12:00", true},
+	}
+
+	for _, tc := range tests {
+		w, err := Parse(tc.window)
+		if err != nil {
+			t.Fatalf("Failed parsing window %q: %v", tc.window, err)
+		}
+
+		clock, _ := time.Parse("15:04", tc.clock)
+		if got := w.Contains(clock); got != tc.want {
+			t.Errorf("Expected %q contains %s to be %v but got %v", tc.window, tc.clock, tc.want, got)
+		}
+	}
+}
+
+/* Test Next Window Start */
+
+func TestNextStart(t *testing.T) {
+	windows, err := ParseList([]string{"01:00-07:00", "13:00-14:00"})
+	if err != nil {
+		t.Fatalf("Failed parsing windows: %v", err)
+	}
+
+	now := time.Date(2020, 1, 1, 8, 0, 0, 0, time.UTC)
+	if next := NextStart(windows, now); !next.Equal(time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected next start today at 13:00 but got %s", next)
+	}
+
+	now = time.Date(2020, 1, 1, 15, 0, 0, 0, time.UTC)
+	if next := NextStart(windows, now); !next.Equal(time.Date(2020, 1, 2, 1, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected next start tomorrow at 01:00 but got %s", next)
+	}
+}
+
+/* Test Invalid Window */
+
+func TestParseInvalid(t *testing.T) {
+	for _, text := range []string{"", "01:00", "1am-7am", "01:00-25:00"} {
+		if _, err := Parse(text); err == nil {
+			t.Errorf("Expected error parsing %q", text)
+		}
+	}
+}